@@ -1,26 +1,53 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
-	"errors"
-	"fmt"
 	"io/ioutil"
+	"log"
 	"net/http"
 	"net/url"
+	"strconv"
+	"sync"
 	"time"
-
-	"github.com/WhiteAcres/leaguestats/config"
 )
 
 // Client - League API Client Object
 type Client struct {
+	// Platform selects the platform and regional hosts each endpoint is sent to
+	Platform Platform
+	// BaseURL, when set, overrides every host (e.g. for a proxy or a test server)
 	BaseURL    *url.URL
 	APIKey     string
 	HTTPClient *http.Client
+	// Timeout bounds each attempt at a request; DefaultTimeout if zero, none if negative
+	Timeout time.Duration
+	// Retry is the policy for retrying transient server errors; see DefaultRetryPolicy
+	Retry RetryPolicy
+	// Debug, if set, logs every request and response with API keys redacted
+	Debug *log.Logger
+	// OnUnauthorized, if set, is called when the API key is rejected and returns a
+	// new key to retry the request with once. Without it ErrUnauthorized is returned.
+	OnUnauthorized func() (string, error)
+
+	limiterOnce sync.Once
+	limiter     *rateLimiter
+	keyMu       sync.Mutex
+}
+
+// Account - Account Object from the Account-V1 API
+type Account struct {
+	PuuID    string
+	GameName string
+	TagLine  string
+	// Platform is the platform the account was looked up for; Riot doesn't send it
+	Platform Platform
 }
 
 // SummonerInfo - SummonerInfo Object from League API
 type SummonerInfo struct {
+	// Platform is the platform the summoner was looked up on
+	Platform      Platform
 	ID            string
 	AccountID     string
 	PuuID         string
@@ -30,339 +57,292 @@ type SummonerInfo struct {
 	SummonerLevel int64
 }
 
-// MatchReference - MatchReference Object from League API
-type MatchReference struct {
-	Lane       string
-	GameID     int64
-	Champion   int64
-	PlatformID string
-	Season     int64
-	Queue      int64
-	Role       string
-	Timestamp  int64
-}
-
-// Matchlist - Matchlist Object from League API
-type Matchlist struct {
-	Matches    []MatchReference
-	TotalGames int64
-	StartIndex int64
-	EndIndex   int64
+// platformURL resolves an endpoint path against the client's platform host
+func (c *Client) platformURL(path string) *url.URL {
+	base := c.BaseURL
+	if base == nil {
+		base = c.Platform.URL()
+	}
+	return base.ResolveReference(&url.URL{Path: path})
 }
 
-// Match - info regarding a partiular match
-type Match struct {
-	SeasonID              int64
-	QueueID               int64
-	GameID                int64
-	ParticipantIdentities []ParticipantIdentity
-	GameVersion           string
-	PlatformID            string
-	GameMode              string
-	MapID                 int64
-	GameType              string
-	Teams                 []TeamStats
-	Participants          []Participant
-	GameDuration          int64
-	GameCreation          int64
+// regionalURL resolves an endpoint path against a regional host
+func (c *Client) regionalURL(region Region, path string) *url.URL {
+	base := c.BaseURL
+	if base == nil {
+		base = region.URL()
+	}
+	return base.ResolveReference(&url.URL{Path: path})
 }
 
-// ParticipantIdentity - info regarding paricipant identity
-type ParticipantIdentity struct {
-	Player        Player
-	ParticipantID int64
-}
+// DefaultTimeout is how long a request attempt may take when Client.Timeout is zero
+const DefaultTimeout = 30 * time.Second
 
-// Player - info regarding player
-type Player struct {
-	CurrentPlatformID string
-	SummonerName      string
-	MatchHistoryURI   string
-	PlatformID        string
-	CurrentAccountID  string
-	ProfileIcon       int
-	SummonerID        string
-	AccountID         string
-}
+// maxRateLimitRetries is how many 429 responses a request waits out before giving up
+const maxRateLimitRetries = 5
 
-// TeamStats - info regarding team stats
-type TeamStats struct {
-	FirstDragon          bool
-	FirstInhibitor       bool
-	Bans                 []TeamBans
-	BaronKills           int64
-	FirstRiftHerald      bool
-	FirstBaron           bool
-	RiftHeraldKills      int64
-	FirstBlood           bool
-	TeamID               int64
-	FirstTower           bool
-	VilemawKills         int64
-	InhibitorKills       int64
-	TowerKills           int64
-	DominionVictoryScore int64
-	Win                  string
-	DragonKills          int64
+// rateLimiter returns the client's rate limiter, creating it on first use
+func (c *Client) rateLimiter() *rateLimiter {
+	c.limiterOnce.Do(func() {
+		c.limiter = newRateLimiter()
+	})
+	return c.limiter
 }
 
-// TeamBans - info regarding team bans
-type TeamBans struct {
-	PickTurn   int64
-	ChampionID int64
+// LeagueAPIRequest sends request to League API. The endpoint names the API method
+// (e.g. match-v5.getMatch) so its method rate limit can be tracked.
+func (c *Client) LeagueAPIRequest(method, endpoint string, u *url.URL) ([]byte, error) {
+	return c.LeagueAPIRequestContext(context.Background(), method, endpoint, u)
 }
 
-// Participant - info regarding a participant
-type Participant struct {
-	Stats                     ParticipantStats
-	ParticipantID             int64
-	Runes                     []Rune
-	Timeline                  ParticipantTimeline
-	TeamID                    int64
-	Spell2ID                  int64
-	Masteries                 []Mastery
-	HighestAchievedSeasonTier string
-	Sepll1ID                  int64
-	ChampionID                int64
-}
+// LeagueAPIRequestContext - LeagueAPIRequest with a context for cancellation and deadlines.
+// Each attempt is also bounded by the client's Timeout.
+func (c *Client) LeagueAPIRequestContext(ctx context.Context, method, endpoint string, u *url.URL) ([]byte, error) {
+	limiter := c.rateLimiter()
+	retry := c.Retry.withDefaults()
+	refreshedKey := false
+	serverErrors := 0
+	for attempt := 0; ; attempt++ {
+		apiKey := c.apiKey()
+		// Waiting until the rate limits allow the request
+		if err := limiter.wait(ctx, u.Host, endpoint); err != nil {
+			return nil, err
+		}
+		status, header, body, err := c.do(ctx, method, u, apiKey)
+		if err != nil {
+			return nil, c.redactError(err)
+		}
+		limiter.update(u.Host, endpoint, header)
+		if status >= 200 && status < 300 {
+			return body, nil
+		}
 
-// ParticipantStats - info regarding participant stats
-type ParticipantStats struct {
-	FirstBloodAssist                bool
-	VisionScore                     int64
-	MagicDamageDealtToChampions     int64
-	DamageDealtToObjectives         int64
-	TotalTimeCrowdControlDealt      int64
-	LongestTimeSpentLiving          int64
-	Perk1Var1                       int64
-	Perk1Var3                       int64
-	Perk1Var2                       int64
-	TripleKills                     int64
-	Perk3Var3                       int64
-	NodeNeutralizeAssist            int64
-	Perk3Var2                       int64
-	PlayerScore9                    int64
-	PlayerScore8                    int64
-	kills                           int64
-	PlayerScore1                    int64
-	PlayerScore0                    int64
-	PlayerScore3                    int64
-	PlayerScore2                    int64
-	PlayerScore5                    int64
-	PlayerScore4                    int64
-	PlayerScore7                    int64
-	PlayerScore6                    int64
-	Perk5Var1                       int64
-	Perk5Var3                       int64
-	Perk5Var2                       int64
-	TotalScoreRank                  int64
-	NeutralMinionsKilled            int64
-	DamageDealtToTurrets            int64
-	PhysicalDamageDealtToChampions  int64
-	NodeCapture                     int64
-	LargestMultiKill                int64
-	Perk2Var2                       int64
-	Perk2Var3                       int64
-	TotalUnitsHealed                int64
-	Perk2Var1                       int64
-	Perk4Var1                       int64
-	Perk4Var2                       int64
-	Perk4Var3                       int64
-	WardsKilled                     int64
-	LargestCriticalStrike           int64
-	LargestKillingSpree             int64
-	QuadraKills                     int64
-	TeamObjective                   int64
-	MagicDamageDealt                int64
-	Item2                           int64
-	Item3                           int64
-	Item0                           int64
-	NeutralMinionsKilledTeamJungle  int64
-	Item6                           int64
-	Item4                           int64
-	Item5                           int64
-	Perk1                           int64
-	Perk0                           int64
-	Perk3                           int64
-	Perk2                           int64
-	Perk5                           int64
-	Perk4                           int64
-	Perk3Var1                       int64
-	DamageSelfMitigated             int64
-	MagicalDamageTaken              int64
-	FirstInhibitorKilled            bool
-	TrueDamageTaken                 int64
-	NodeNeutralize                  int64
-	Assists                         int64
-	CombatPlayerScore               int64
-	PerkPrimaryStyle                int64
-	GoldSpent                       int64
-	TrueDamageDealt                 int64
-	ParticipantID                   int64
-	TotalDamageTaken                int64
-	PhysicalDamageDealt             int64
-	SightWardsBoughtInGame          int64
-	TotalDamageDealtToChampions     int64
-	PhysicalDamageTaken             int64
-	TotalPlayerScore                int64
-	Win                             bool
-	ObjectivePlayerScore            int64
-	TotalDamageDealt                int64
-	Item1                           int64
-	NeutralMinionsKilledEnemyJungle int64
-	Deaths                          int64
-	WardsPlaced                     int64
-	PerkSubStyle                    int64
-	TurretKills                     int64
-	FirstBloodKill                  bool
-	TrueDamageDealtToChampions      int64
-	GoldEarned                      int64
-	KillingSprees                   int64
-	UnrealKills                     int64
-	AltersCaptured                  int64
-	FirstTowerAssist                bool
-	FirstTowerKill                  bool
-	ChampLevel                      int64
-	DoubleKills                     int64
-	NodeCaptureAssist               int64
-	InhibitorKills                  int64
-	FirstInhibitorAssist            bool
-	Perk0Var1                       int64
-	Perk0Var2                       int64
-	Perk0Var3                       int64
-	VisionWardsBoughtInGame         int64
-	AltarsNeutralized               int64
-	PentaKills                      int64
-	TotalHeal                       int64
-	TotalMinionsKilled              int64
-	TimeCCingOthers                 int64
+		apiErr := newAPIError(endpoint, status, body)
+		apiErr.Status.Message = c.redact(apiErr.Status.Message)
+		if status == 429 && attempt < maxRateLimitRetries {
+			wait := limiter.backoff(u.Host, endpoint, header)
+			c.logf("Rate limited by %s, retrying in %s", endpoint, wait)
+			continue
+		} else if retry.shouldRetry(method, status, serverErrors) {
+			wait := retry.delay(serverErrors)
+			serverErrors++
+			c.logf("%v, retrying in %s", apiErr, wait.Round(time.Millisecond))
+			if err := sleep(ctx, wait); err != nil {
+				return nil, err
+			}
+			continue
+		} else if (status == 401 || status == 403) && c.OnUnauthorized != nil && !refreshedKey {
+			refreshedKey = true
+			if err := c.refreshAPIKey(apiKey); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		return nil, apiErr
+	}
 }
 
-// Rune - info regarding a rune
-type Rune struct {
-	RuneID int64
-	Rank   int64
+// logf logs to the client's Debug logger, if any, keeping stdout for the caller's output
+func (c *Client) logf(format string, v ...interface{}) {
+	if c.Debug != nil {
+		c.Debug.Printf(format, v...)
+	}
 }
 
-// ParticipantTimeline - info regarding a participant timeline
-type ParticipantTimeline struct {
-	Lane                        string
-	ParticipantID               int64
-	CSDiffPerMinuteDeltas       map[string]float64
-	GoldPerMinDeltas            map[string]float64
-	XPDiffPerMinDeltas          map[string]float64
-	CreepsPerMinDeltas          map[string]float64
-	XPPerMinDeltas              map[string]float64
-	Role                        string
-	DamageTakenDiffPerMinDeltas map[string]float64
-	DamageTakenPerMinDeltas     map[string]float64
+// apiKey returns the key requests are currently sent with
+func (c *Client) apiKey() string {
+	c.keyMu.Lock()
+	defer c.keyMu.Unlock()
+	return c.APIKey
 }
 
-// Mastery - info regarding mastery
-type Mastery struct {
-	MasterID int64
-	Rank     int64
+// refreshAPIKey asks OnUnauthorized for a new key to replace rejected, unless a
+// concurrent request has already replaced it
+func (c *Client) refreshAPIKey(rejected string) error {
+	c.keyMu.Lock()
+	defer c.keyMu.Unlock()
+	if c.APIKey != rejected {
+		return nil
+	}
+	APIKey, err := c.OnUnauthorized()
+	if err != nil {
+		return err
+	}
+	c.APIKey = APIKey
+	return nil
 }
 
-// LeagueAPIRequest sends request to League API
-func (c *Client) LeagueAPIRequest(method string, u *url.URL) ([]byte, error) {
-	resp := &http.Response{}
-	// Add api key to url
-	q, _ := url.ParseQuery(u.RawQuery)
-	q.Add("api_key", c.APIKey)
-	u.RawQuery = q.Encode()
+// do sends a single request, bounded by the client's Timeout, and reads the whole response.
+// The API key goes in the X-Riot-Token header, never the URL, so it stays out of logs.
+func (c *Client) do(ctx context.Context, method string, u *url.URL, apiKey string) (int, http.Header, []byte, error) {
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
 
 	// Creating the request
-	req, err := http.NewRequest(method, u.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
 	if err != nil {
-		fmt.Println(err)
-		return nil, err
+		return 0, nil, nil, err
 	}
 	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Riot-Token", apiKey)
+	c.debugRequest(req)
 
 	// Sending the request
-	resp, err = c.HTTPClient.Do(req)
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
 	if err != nil {
-		fmt.Println(err)
-		return nil, err
-	} else if resp.StatusCode == 403 {
-		APIKey := config.GetNewAPIKey("API Key was unauthorized (probably expired)")
-		c.APIKey = APIKey
-		updates := map[string]string{"APIKey": APIKey}
-		config.UpdateConfig(updates)
-		time.Sleep(5 * time.Second)
-	} else if resp.StatusCode == 404 {
-		return nil, errors.New("Invalid Summoner Name")
-	} else if resp.StatusCode == 429 {
-		return nil, errors.New("API rate limit exceeded! Wait a few minutes, and try again.")
-	} else if resp.StatusCode < 200 || resp.StatusCode > 300 {
-		return nil, errors.New("API Error")
+		return 0, nil, nil, err
 	}
 
 	// Translating response
 	defer resp.Body.Close()
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		fmt.Println(err)
+		return 0, nil, nil, err
+	}
+	c.debugResponse(resp, body)
+	return resp.StatusCode, resp.Header, body, nil
+}
+
+// GetAccountByRiotID - Gets the Account for a Riot ID (gameName#tagLine) from League API
+func (c *Client) GetAccountByRiotID(gameName, tagLine string) (*Account, error) {
+	return c.GetAccountByRiotIDContext(context.Background(), gameName, tagLine)
+}
+
+// GetAccountByRiotIDContext - GetAccountByRiotID with a context for cancellation and deadlines
+func (c *Client) GetAccountByRiotIDContext(ctx context.Context, gameName, tagLine string) (*Account, error) {
+	// Creating the url
+	u := c.regionalURL(c.Platform.AccountRegion(), "/riot/account/v1/accounts/by-riot-id/"+gameName+"/"+tagLine)
+
+	body, err := c.LeagueAPIRequestContext(ctx, "GET", "account-v1.getByRiotId", u)
+	if err != nil {
+		return nil, err
+	}
+	var a Account
+	err = json.Unmarshal(body, &a)
+	if err != nil {
 		return nil, err
 	}
-	return body, nil
+	a.Platform = c.Platform
+	return &a, nil
+}
+
+// GetSummonerByPUUID - Gets Summoner Info from League API
+func (c *Client) GetSummonerByPUUID(puuid string) (*SummonerInfo, error) {
+	return c.GetSummonerByPUUIDContext(context.Background(), puuid)
 }
 
-// GetSummonerInfo - Gets Summoner Info from League API
-func (c *Client) GetSummonerInfo(name string) (*SummonerInfo, error) {
+// GetSummonerByPUUIDContext - GetSummonerByPUUID with a context for cancellation and deadlines
+func (c *Client) GetSummonerByPUUIDContext(ctx context.Context, puuid string) (*SummonerInfo, error) {
 	// Creating the url
-	rel := &url.URL{Path: "/lol/summoner/v4/summoners/by-name/" + name}
-	u := c.BaseURL.ResolveReference(rel)
+	u := c.platformURL("/lol/summoner/v4/summoners/by-puuid/" + puuid)
 
-	// Creating the request
-	body, err := c.LeagueAPIRequest("GET", u)
+	body, err := c.LeagueAPIRequestContext(ctx, "GET", "summoner-v4.getByPUUID", u)
 	if err != nil {
-		fmt.Println(err)
 		return nil, err
 	}
 	var si SummonerInfo
 	err = json.Unmarshal(body, &si)
 	if err != nil {
-		fmt.Println(err)
 		return nil, err
 	}
+	si.Platform = c.Platform
 	return &si, nil
 }
 
-// GetMatchList - Gets the match List from the League API
-func (c *Client) GetMatchList(accountID string) (*Matchlist, error) {
+// GetMatchIDs - Gets one page of a player's match IDs, most recent first, from the League API
+func (c *Client) GetMatchIDs(puuid string, q MatchIDQuery) ([]string, error) {
+	return c.GetMatchIDsContext(context.Background(), puuid, q)
+}
+
+// GetMatchIDsContext - GetMatchIDs with a context for cancellation and deadlines
+func (c *Client) GetMatchIDsContext(ctx context.Context, puuid string, q MatchIDQuery) ([]string, error) {
 	// Creating the url
-	rel := &url.URL{Path: "/lol/match/v4/matchlists/by-account/" + accountID}
-	u := c.BaseURL.ResolveReference(rel)
+	u := c.regionalURL(c.Platform.Region(), "/lol/match/v5/matches/by-puuid/"+puuid+"/ids")
+	u.RawQuery = q.values().Encode()
 
-	body, err := c.LeagueAPIRequest("GET", u)
+	body, err := c.LeagueAPIRequestContext(ctx, "GET", "match-v5.getMatchIdsByPUUID", u)
 	if err != nil {
-		fmt.Println(err)
 		return nil, err
 	}
-	var ml Matchlist
-	err = json.Unmarshal(body, &ml)
+	var ids []string
+	err = json.Unmarshal(body, &ids)
 	if err != nil {
-		fmt.Println(err)
 		return nil, err
 	}
-	return &ml, nil
+	return ids, nil
 }
 
 // GetMatch - gets match information
 func (c *Client) GetMatch(matchID string) (*Match, error) {
+	return c.GetMatchContext(context.Background(), matchID)
+}
+
+// GetMatchContext - GetMatch with a context for cancellation and deadlines
+func (c *Client) GetMatchContext(ctx context.Context, matchID string) (*Match, error) {
 	// Creating the url
-	rel := &url.URL{Path: "/lol/match/v4/matches/" + matchID}
-	u := c.BaseURL.ResolveReference(rel)
-	body, err := c.LeagueAPIRequest("GET", u)
+	u := c.regionalURL(c.Platform.Region(), "/lol/match/v5/matches/"+matchID)
+	body, err := c.LeagueAPIRequestContext(ctx, "GET", "match-v5.getMatch", u)
 	if err != nil {
-		fmt.Println(err)
 		return nil, err
 	}
 	var m Match
 	err = json.Unmarshal(body, &m)
 	if err != nil {
-		fmt.Println(err)
+		return nil, err
+	}
+	return &m, nil
+}
+
+// GetMatchListV4 - Gets one page of the match List from the retired Match-V4 API
+func (c *Client) GetMatchListV4(accountID string, q MatchListQuery) (*Matchlist, error) {
+	return c.GetMatchListV4Context(context.Background(), accountID, q)
+}
+
+// GetMatchListV4Context - GetMatchListV4 with a context for cancellation and deadlines
+func (c *Client) GetMatchListV4Context(ctx context.Context, accountID string, q MatchListQuery) (*Matchlist, error) {
+	// Creating the url
+	u := c.platformURL("/lol/match/v4/matchlists/by-account/" + accountID)
+	u.RawQuery = q.values().Encode()
+
+	body, err := c.LeagueAPIRequestContext(ctx, "GET", "match-v4.getMatchlist", u)
+	if err != nil {
+		return nil, err
+	}
+	var ml Matchlist
+	err = json.Unmarshal(body, &ml)
+	if err != nil {
+		return nil, err
+	}
+	return &ml, nil
+}
+
+// GetMatchV4 - gets match information from the retired Match-V4 API
+func (c *Client) GetMatchV4(gameID int64) (*MatchV4, error) {
+	return c.GetMatchV4Context(context.Background(), gameID)
+}
+
+// GetMatchV4Context - GetMatchV4 with a context for cancellation and deadlines
+func (c *Client) GetMatchV4Context(ctx context.Context, gameID int64) (*MatchV4, error) {
+	// Creating the url
+	u := c.platformURL("/lol/match/v4/matches/" + strconv.FormatInt(gameID, 10))
+	body, err := c.LeagueAPIRequestContext(ctx, "GET", "match-v4.getMatch", u)
+	if err != nil {
+		return nil, err
+	}
+	var m MatchV4
+	err = json.Unmarshal(body, &m)
+	if err != nil {
 		return nil, err
 	}
 	return &m, nil
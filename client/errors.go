@@ -0,0 +1,90 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors for the usual API failures, matched by an *APIError with errors.Is
+var (
+	ErrNotFound           = errors.New("not found")
+	ErrUnauthorized       = errors.New("API key unauthorized (missing, invalid or expired)")
+	ErrRateLimited        = errors.New("API rate limit exceeded")
+	ErrServiceUnavailable = errors.New("API service unavailable")
+)
+
+// RiotStatus - the status object Riot returns in the body of a failed request
+type RiotStatus struct {
+	Message    string
+	StatusCode int `json:"status_code"`
+}
+
+// APIError - a League API request that returned a non-2xx status
+type APIError struct {
+	// StatusCode is the HTTP status of the response
+	StatusCode int
+	// Endpoint is the API method requested (e.g. match-v5.getMatch)
+	Endpoint string
+	// Status is the status Riot sent in the response body, if any
+	Status RiotStatus
+	// Retryable reports whether the same request may succeed later
+	Retryable bool
+}
+
+// newAPIError builds the error for a failed response, decoding Riot's status body
+func newAPIError(endpoint string, statusCode int, body []byte) *APIError {
+	var wrapper struct {
+		Status RiotStatus
+	}
+	json.Unmarshal(body, &wrapper)
+	return &APIError{
+		StatusCode: statusCode,
+		Endpoint:   endpoint,
+		Status:     wrapper.Status,
+		Retryable:  statusCode == 429 || transientStatus(statusCode),
+	}
+}
+
+func (e *APIError) Error() string {
+	msg := e.Status.Message
+	if len(msg) == 0 {
+		if sentinel := e.sentinel(); sentinel != nil {
+			msg = sentinel.Error()
+		} else {
+			msg = "API error"
+		}
+	}
+	return fmt.Sprintf("%s: %d %s", e.Endpoint, e.StatusCode, msg)
+}
+
+// Is lets errors.Is match an APIError against the sentinel for its status
+func (e *APIError) Is(target error) bool {
+	return target != nil && e.sentinel() == target
+}
+
+// sentinel returns the sentinel error for the status, or nil if there is none
+func (e *APIError) sentinel() error {
+	switch e.StatusCode {
+	case 401, 403:
+		return ErrUnauthorized
+	case 404:
+		return ErrNotFound
+	case 429:
+		return ErrRateLimited
+	}
+	if transientStatus(e.StatusCode) {
+		return ErrServiceUnavailable
+	}
+	return nil
+}
+
+// transientStatus reports whether a server error status is one that may clear up,
+// so the request is worth sending again
+func transientStatus(statusCode int) bool {
+	switch statusCode {
+	case 500, 502, 503, 504:
+		return true
+	}
+	return false
+}
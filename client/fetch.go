@@ -0,0 +1,132 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// DefaultFetchWorkers is how many matches FetchMatches downloads at once by default
+const DefaultFetchWorkers = 4
+
+// FetchOptions - options for FetchMatches
+type FetchOptions struct {
+	// Workers bounds how many matches are downloaded at once; DefaultFetchWorkers if zero
+	Workers int
+	// Progress, if set, is called after each match with the number done so far and the total
+	Progress func(done, total int)
+}
+
+// MatchResult - the outcome of downloading one match
+type MatchResult struct {
+	MatchID string
+	Match   *Match
+	Err     error
+}
+
+// FetchMatches downloads matches with a bounded pool of workers and streams each
+// result as it completes, in no particular order. A failed match is reported
+// through MatchResult.Err rather than stopping the rest. All workers share the
+// client's rate limiter, so adding workers never exceeds Riot's limits. The
+// channel is closed once every match is done, or early if ctx is cancelled.
+func (c *Client) FetchMatches(ctx context.Context, matchIDs []string, opts FetchOptions) <-chan MatchResult {
+	results := make(chan MatchResult)
+	fetchEach(ctx, matchIDs, opts, func(matchID string) bool {
+		m, err := c.GetMatchContext(ctx, matchID)
+		select {
+		case results <- MatchResult{MatchID: matchID, Match: m, Err: err}:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}, func() { close(results) })
+	return results
+}
+
+// TimelineResult - the outcome of downloading one match timeline
+type TimelineResult struct {
+	MatchID  string
+	Timeline *Timeline
+	Err      error
+}
+
+// FetchTimelines downloads the Match-V5 timelines of matches the way FetchMatches
+// downloads the matches
+func (c *Client) FetchTimelines(ctx context.Context, matchIDs []string, opts FetchOptions) <-chan TimelineResult {
+	results := make(chan TimelineResult)
+	fetchEach(ctx, matchIDs, opts, func(matchID string) bool {
+		t, err := c.GetMatchTimelineContext(ctx, matchID)
+		select {
+		case results <- TimelineResult{MatchID: matchID, Timeline: t, Err: err}:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}, func() { close(results) })
+	return results
+}
+
+// fetchEach calls fetch for every match ID from a bounded pool of workers, reporting
+// progress, until fetch returns false or ctx is cancelled. done is called once
+// every worker has stopped.
+func fetchEach(ctx context.Context, matchIDs []string, opts FetchOptions, fetch func(matchID string) bool, done func()) {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = DefaultFetchWorkers
+	}
+
+	jobs := make(chan string)
+	var mu sync.Mutex
+	fetched := 0
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for matchID := range jobs {
+				if !fetch(matchID) {
+					return
+				}
+				if opts.Progress != nil {
+					mu.Lock()
+					fetched++
+					opts.Progress(fetched, len(matchIDs))
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for _, matchID := range matchIDs {
+			select {
+			case jobs <- matchID:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		done()
+	}()
+}
+
+// FetchErrors - the matches that failed to download, keyed by match ID
+type FetchErrors map[string]error
+
+func (fe FetchErrors) Error() string {
+	matchIDs := make([]string, 0, len(fe))
+	for matchID := range fe {
+		matchIDs = append(matchIDs, matchID)
+	}
+	sort.Strings(matchIDs)
+	if len(matchIDs) == 1 {
+		return fmt.Sprintf("failed to fetch %s: %v", matchIDs[0], fe[matchIDs[0]])
+	}
+	return fmt.Sprintf("failed to fetch %d matches, first %s: %v", len(matchIDs), matchIDs[0], fe[matchIDs[0]])
+}
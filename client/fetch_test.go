@@ -0,0 +1,131 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// matchServer serves Match-V5 matches by ID after a short delay, answering 404 for
+// IDs ending in "missing", and records the most requests it had in flight at once
+func matchServer(t *testing.T, maxInFlight *int32) *Client {
+	var inFlight int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			max := atomic.LoadInt32(maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(maxInFlight, max, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		matchID := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		if strings.HasSuffix(matchID, "missing") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"Metadata": {"MatchID": %q}}`, matchID)
+	}))
+	t.Cleanup(server.Close)
+	u, _ := url.Parse(server.URL)
+	return &Client{BaseURL: u, APIKey: "test"}
+}
+
+func TestFetchMatches(t *testing.T) {
+	var maxInFlight int32
+	c := matchServer(t, &maxInFlight)
+	var matchIDs []string
+	for i := 0; i < 12; i++ {
+		matchIDs = append(matchIDs, fmt.Sprintf("NA1_%d", i))
+	}
+	matchIDs[5] = "NA1_missing"
+
+	var mu sync.Mutex
+	var lastDone, lastTotal int
+	opts := FetchOptions{Workers: 3, Progress: func(done, total int) {
+		mu.Lock()
+		defer mu.Unlock()
+		if done > lastDone {
+			lastDone, lastTotal = done, total
+		}
+	}}
+	results := make(map[string]MatchResult)
+	for result := range c.FetchMatches(context.Background(), matchIDs, opts) {
+		results[result.MatchID] = result
+	}
+
+	if len(results) != len(matchIDs) {
+		t.Fatalf("got %d results, want %d", len(results), len(matchIDs))
+	}
+	for _, matchID := range matchIDs {
+		result := results[matchID]
+		if matchID == "NA1_missing" {
+			if !errors.Is(result.Err, ErrNotFound) {
+				t.Errorf("missing match err = %v, want ErrNotFound", result.Err)
+			}
+			continue
+		}
+		if result.Err != nil || result.Match == nil || result.Match.Metadata.MatchID != matchID {
+			t.Errorf("result for %s = %+v", matchID, result)
+		}
+	}
+	if n := atomic.LoadInt32(&maxInFlight); n > 3 {
+		t.Errorf("%d requests in flight at once, want at most Workers = 3", n)
+	}
+	if lastDone != len(matchIDs) || lastTotal != len(matchIDs) {
+		t.Errorf("progress ended at %d/%d, want %d/%d", lastDone, lastTotal, len(matchIDs), len(matchIDs))
+	}
+}
+
+func TestFetchMatchesCancel(t *testing.T) {
+	// The server holds every request until the client gives up on it
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+	baseline := runtime.NumGoroutine()
+
+	transport := &http.Transport{}
+	c := &Client{BaseURL: u, APIKey: "test", HTTPClient: &http.Client{Transport: transport}}
+	ctx, cancel := context.WithCancel(context.Background())
+	var matchIDs []string
+	for i := 0; i < 20; i++ {
+		matchIDs = append(matchIDs, fmt.Sprintf("NA1_%d", i))
+	}
+	results := c.FetchMatches(ctx, matchIDs, FetchOptions{Workers: 4})
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	closed := make(chan struct{})
+	go func() {
+		for range results {
+		}
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("results channel not closed after cancelling")
+	}
+
+	transport.CloseIdleConnections()
+	server.CloseClientConnections()
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > baseline {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<16)
+			t.Fatalf("%d goroutines left running, %d before:\n%s", runtime.NumGoroutine(), baseline, buf[:runtime.Stack(buf, true)])
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
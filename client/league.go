@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Ranked queue types of League-V4 entries
+const (
+	QueueTypeSolo = "RANKED_SOLO_5x5"
+	QueueTypeFlex = "RANKED_FLEX_SR"
+)
+
+// LeagueEntry - LeagueEntry Object from the League-V4 API: a player's standing in one ranked queue
+type LeagueEntry struct {
+	LeagueID     string
+	SummonerID   string
+	PuuID        string
+	QueueType    string
+	Tier         string
+	Rank         string
+	LeaguePoints int64
+	Wins         int64
+	Losses       int64
+	HotStreak    bool
+	Veteran      bool
+	FreshBlood   bool
+	Inactive     bool
+	// MiniSeries is only set while the player is in a promotion series
+	MiniSeries *MiniSeries
+}
+
+// MiniSeries - MiniSeries Object from the League-V4 API
+type MiniSeries struct {
+	Losses   int64
+	Progress string
+	Target   int64
+	Wins     int64
+}
+
+// GetLeagueEntries - Gets a player's ranked standing in each queue they are placed in
+func (c *Client) GetLeagueEntries(puuid string) ([]LeagueEntry, error) {
+	return c.GetLeagueEntriesContext(context.Background(), puuid)
+}
+
+// GetLeagueEntriesContext - GetLeagueEntries with a context for cancellation and deadlines
+func (c *Client) GetLeagueEntriesContext(ctx context.Context, puuid string) ([]LeagueEntry, error) {
+	// Creating the url
+	u := c.platformURL("/lol/league/v4/entries/by-puuid/" + puuid)
+
+	body, err := c.LeagueAPIRequestContext(ctx, "GET", "league-v4.getLeagueEntriesByPUUID", u)
+	if err != nil {
+		return nil, err
+	}
+	var entries []LeagueEntry
+	err = json.Unmarshal(body, &entries)
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// GetLeagueEntriesBySummoner - Gets a summoner's ranked standing by encrypted summoner ID
+func (c *Client) GetLeagueEntriesBySummoner(summonerID string) ([]LeagueEntry, error) {
+	return c.GetLeagueEntriesBySummonerContext(context.Background(), summonerID)
+}
+
+// GetLeagueEntriesBySummonerContext - GetLeagueEntriesBySummoner with a context for cancellation and deadlines
+func (c *Client) GetLeagueEntriesBySummonerContext(ctx context.Context, summonerID string) ([]LeagueEntry, error) {
+	// Creating the url
+	u := c.platformURL("/lol/league/v4/entries/by-summoner/" + summonerID)
+
+	body, err := c.LeagueAPIRequestContext(ctx, "GET", "league-v4.getLeagueEntriesForSummoner", u)
+	if err != nil {
+		return nil, err
+	}
+	var entries []LeagueEntry
+	err = json.Unmarshal(body, &entries)
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
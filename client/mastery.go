@@ -0,0 +1,69 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
+)
+
+// ChampionMastery - ChampionMastery Object from the Champion-Mastery-V4 API
+type ChampionMastery struct {
+	PuuID                        string
+	ChampionID                   int64
+	ChampionLevel                int64
+	ChampionPoints               int64
+	ChampionPointsSinceLastLevel int64
+	ChampionPointsUntilNextLevel int64
+	// LastPlayTime is when the champion was last played, in milliseconds since the epoch
+	LastPlayTime int64
+	TokensEarned int64
+}
+
+// GetChampionMasteries - Gets a player's mastery of every champion they have played,
+// most points first
+func (c *Client) GetChampionMasteries(puuid string) ([]ChampionMastery, error) {
+	return c.GetChampionMasteriesContext(context.Background(), puuid)
+}
+
+// GetChampionMasteriesContext - GetChampionMasteries with a context for cancellation and deadlines
+func (c *Client) GetChampionMasteriesContext(ctx context.Context, puuid string) ([]ChampionMastery, error) {
+	// Creating the url
+	u := c.platformURL("/lol/champion-mastery/v4/champion-masteries/by-puuid/" + puuid)
+
+	body, err := c.LeagueAPIRequestContext(ctx, "GET", "champion-mastery-v4.getAllChampionMasteriesByPUUID", u)
+	if err != nil {
+		return nil, err
+	}
+	var masteries []ChampionMastery
+	err = json.Unmarshal(body, &masteries)
+	if err != nil {
+		return nil, err
+	}
+	return masteries, nil
+}
+
+// GetTopChampionMasteries - Gets a player's count most mastered champions (Riot defaults to 3 if count is 0)
+func (c *Client) GetTopChampionMasteries(puuid string, count int) ([]ChampionMastery, error) {
+	return c.GetTopChampionMasteriesContext(context.Background(), puuid, count)
+}
+
+// GetTopChampionMasteriesContext - GetTopChampionMasteries with a context for cancellation and deadlines
+func (c *Client) GetTopChampionMasteriesContext(ctx context.Context, puuid string, count int) ([]ChampionMastery, error) {
+	// Creating the url
+	u := c.platformURL("/lol/champion-mastery/v4/champion-masteries/by-puuid/" + puuid + "/top")
+	if count > 0 {
+		u.RawQuery = url.Values{"count": {strconv.Itoa(count)}}.Encode()
+	}
+
+	body, err := c.LeagueAPIRequestContext(ctx, "GET", "champion-mastery-v4.getTopChampionMasteriesByPUUID", u)
+	if err != nil {
+		return nil, err
+	}
+	var masteries []ChampionMastery
+	err = json.Unmarshal(body, &masteries)
+	if err != nil {
+		return nil, err
+	}
+	return masteries, nil
+}
@@ -0,0 +1,177 @@
+package client
+
+// Match - info regarding a particular match, as served by the Match-V5 API
+type Match struct {
+	Metadata MatchMetadata
+	Info     MatchInfo
+}
+
+// MatchMetadata - info identifying a match and its participants
+type MatchMetadata struct {
+	DataVersion  string
+	MatchID      string
+	Participants []string
+}
+
+// MatchInfo - info regarding the game played in a match
+type MatchInfo struct {
+	GameCreation       int64
+	GameDuration       int64
+	GameEndTimestamp   int64
+	GameID             int64
+	GameMode           string
+	GameName           string
+	GameStartTimestamp int64
+	GameType           string
+	GameVersion        string
+	MapID              int64
+	Participants       []Participant
+	PlatformID         string
+	QueueID            int64
+	Teams              []Team
+	TournamentCode     string
+}
+
+// Participant - info regarding a participant
+type Participant struct {
+	ParticipantID  int64
+	PuuID          string
+	SummonerID     string
+	SummonerName   string
+	RiotIDGameName string
+	RiotIDTagline  string
+	// AccountID is only set on matches converted from Match-V4
+	AccountID   string
+	ProfileIcon int64
+
+	TeamID                    int64
+	ChampionID                int64
+	ChampionName              string
+	ChampLevel                int64
+	Summoner1ID               int64
+	Summoner2ID               int64
+	Lane                      string
+	Role                      string
+	TeamPosition              string
+	IndividualPosition        string
+	Win                       bool
+	GameEndedInSurrender      bool
+	GameEndedInEarlySurrender bool
+
+	Kills                       int64
+	Deaths                      int64
+	Assists                     int64
+	TotalMinionsKilled          int64
+	NeutralMinionsKilled        int64
+	GoldEarned                  int64
+	GoldSpent                   int64
+	TotalDamageDealt            int64
+	TotalDamageDealtToChampions int64
+	TotalDamageTaken            int64
+	DamageDealtToObjectives     int64
+	VisionScore                 int64
+	WardsPlaced                 int64
+	WardsKilled                 int64
+	VisionWardsBoughtInGame     int64
+	TurretKills                 int64
+	FirstBloodKill              bool
+	FirstTowerKill              bool
+	TimePlayed                  int64
+
+	Item0 int64
+	Item1 int64
+	Item2 int64
+	Item3 int64
+	Item4 int64
+	Item5 int64
+	Item6 int64
+}
+
+// Team - info regarding a team
+type Team struct {
+	TeamID     int64
+	Win        bool
+	Bans       []Ban
+	Objectives Objectives
+}
+
+// Ban - info regarding a team ban
+type Ban struct {
+	ChampionID int64
+	PickTurn   int64
+}
+
+// Objectives - info regarding the objectives taken by a team
+type Objectives struct {
+	Baron      Objective
+	Champion   Objective
+	Dragon     Objective
+	Inhibitor  Objective
+	RiftHerald Objective
+	Tower      Objective
+}
+
+// Objective - info regarding a single objective
+type Objective struct {
+	First bool
+	Kills int64
+}
+
+// GameDurationSeconds returns the game length in seconds. Match-V5 reported
+// the duration in milliseconds until GameEndTimestamp was introduced.
+func (m *Match) GameDurationSeconds() int64 {
+	if m.Info.GameEndTimestamp == 0 {
+		return m.Info.GameDuration / 1000
+	}
+	return m.Info.GameDuration
+}
+
+// Positions returns the team positions of Summoner's Rift, in the order of the map
+func Positions() []string {
+	return []string{"TOP", "JUNGLE", "MIDDLE", "BOTTOM", "UTILITY"}
+}
+
+// Position returns the position the participant played (one of Positions()), from
+// TeamPosition when Riot assigned one and otherwise from the lane and role they were
+// seen in, or "" if it can't be told (e.g. in ARAM)
+func (p *Participant) Position() string {
+	if len(p.TeamPosition) > 0 {
+		return p.TeamPosition
+	}
+	if len(p.IndividualPosition) > 0 && p.IndividualPosition != "Invalid" {
+		return p.IndividualPosition
+	}
+	return positionFromLaneRole(p.Lane, p.Role)
+}
+
+// LaneOpponent returns the participant on the other team who played the same position
+func (m *Match) LaneOpponent(p Participant) (Participant, bool) {
+	position := p.Position()
+	if len(position) == 0 {
+		return Participant{}, false
+	}
+	for _, other := range m.Info.Participants {
+		if other.TeamID != p.TeamID && other.Position() == position {
+			return other, true
+		}
+	}
+	return Participant{}, false
+}
+
+// PlayerID returns a stable ID for the player behind the participant, which survives
+// name changes: the PUUID, or for matches converted from Match-V4, which predate
+// PUUIDs, "account:" and the account ID. It is "" for players with neither (e.g. bots).
+func (p *Participant) PlayerID() string {
+	if len(p.PuuID) > 0 && p.PuuID != "BOT" {
+		return p.PuuID
+	}
+	if len(p.AccountID) > 0 && p.AccountID != "0" {
+		return AccountPlayerID(p.AccountID)
+	}
+	return ""
+}
+
+// AccountPlayerID returns the player ID of Match-V4 participants with the account ID
+func AccountPlayerID(accountID string) string {
+	return "account:" + accountID
+}
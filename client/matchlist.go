@@ -0,0 +1,150 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// MaxMatchIDsPerPage is the most match IDs Match-V5 returns per request
+const MaxMatchIDsPerPage = 100
+
+// MaxMatchListV4Range is the widest beginIndex/endIndex range Match-V4 accepts
+const MaxMatchListV4Range = 100
+
+// MatchIDQuery - paging and filters for GetMatchIDs. Zero fields are left out.
+// Match-V5 cannot filter by champion; filter the downloaded matches instead.
+type MatchIDQuery struct {
+	// Start is the index of the first match ID, 0 being the most recent game
+	Start int
+	// Count is the number of match IDs, up to MaxMatchIDsPerPage (Riot defaults to 20)
+	Count     int
+	StartTime time.Time
+	EndTime   time.Time
+	Queue     int64
+	// Type is a match type such as "ranked" or "normal"
+	Type string
+}
+
+func (q MatchIDQuery) values() url.Values {
+	v := url.Values{}
+	if q.Start > 0 {
+		v.Set("start", strconv.Itoa(q.Start))
+	}
+	if q.Count > 0 {
+		v.Set("count", strconv.Itoa(q.Count))
+	}
+	if !q.StartTime.IsZero() {
+		v.Set("startTime", strconv.FormatInt(q.StartTime.Unix(), 10))
+	}
+	if !q.EndTime.IsZero() {
+		v.Set("endTime", strconv.FormatInt(q.EndTime.Unix(), 10))
+	}
+	if q.Queue != 0 {
+		v.Set("queue", strconv.FormatInt(q.Queue, 10))
+	}
+	if len(q.Type) > 0 {
+		v.Set("type", q.Type)
+	}
+	return v
+}
+
+// MatchListQuery - paging and filters for GetMatchListV4. Zero fields are left out.
+type MatchListQuery struct {
+	// BeginIndex and EndIndex select matches [BeginIndex, EndIndex), 0 being the most recent game
+	BeginIndex int
+	EndIndex   int
+	BeginTime  time.Time
+	EndTime    time.Time
+	Queues     []int64
+	Champions  []int64
+}
+
+func (q MatchListQuery) values() url.Values {
+	v := url.Values{}
+	if q.BeginIndex > 0 {
+		v.Set("beginIndex", strconv.Itoa(q.BeginIndex))
+	}
+	if q.EndIndex > 0 {
+		v.Set("endIndex", strconv.Itoa(q.EndIndex))
+	}
+	if !q.BeginTime.IsZero() {
+		v.Set("beginTime", strconv.FormatInt(q.BeginTime.UnixNano()/int64(time.Millisecond), 10))
+	}
+	if !q.EndTime.IsZero() {
+		v.Set("endTime", strconv.FormatInt(q.EndTime.UnixNano()/int64(time.Millisecond), 10))
+	}
+	for _, queue := range q.Queues {
+		v.Add("queue", strconv.FormatInt(queue, 10))
+	}
+	for _, champion := range q.Champions {
+		v.Add("champion", strconv.FormatInt(champion, 10))
+	}
+	return v
+}
+
+// WalkMatchIDs pages through a player's match IDs, most recent first, starting at
+// q.Start and q.Count IDs at a time (MaxMatchIDsPerPage if zero). fn is called with
+// each page and stops the walk by returning false or an error; the walk also ends
+// when the history runs out.
+func (c *Client) WalkMatchIDs(puuid string, q MatchIDQuery, fn func(matchIDs []string) (bool, error)) error {
+	return c.WalkMatchIDsContext(context.Background(), puuid, q, fn)
+}
+
+// WalkMatchIDsContext - WalkMatchIDs with a context for cancellation and deadlines
+func (c *Client) WalkMatchIDsContext(ctx context.Context, puuid string, q MatchIDQuery, fn func(matchIDs []string) (bool, error)) error {
+	if q.Count <= 0 || q.Count > MaxMatchIDsPerPage {
+		q.Count = MaxMatchIDsPerPage
+	}
+	for {
+		matchIDs, err := c.GetMatchIDsContext(ctx, puuid, q)
+		if err != nil {
+			return err
+		}
+		if len(matchIDs) == 0 {
+			return nil
+		}
+		more, err := fn(matchIDs)
+		if err != nil || !more || len(matchIDs) < q.Count {
+			return err
+		}
+		q.Start += len(matchIDs)
+	}
+}
+
+// WalkMatchListV4 pages through a Match-V4 match list, most recent first, starting at
+// q.BeginIndex and MaxMatchListV4Range references at a time, until q.EndIndex if set.
+// fn is called with each page and stops the walk by returning false or an error.
+func (c *Client) WalkMatchListV4(accountID string, q MatchListQuery, fn func(matches []MatchReference) (bool, error)) error {
+	return c.WalkMatchListV4Context(context.Background(), accountID, q, fn)
+}
+
+// WalkMatchListV4Context - WalkMatchListV4 with a context for cancellation and deadlines
+func (c *Client) WalkMatchListV4Context(ctx context.Context, accountID string, q MatchListQuery, fn func(matches []MatchReference) (bool, error)) error {
+	last := q.EndIndex
+	for {
+		q.EndIndex = q.BeginIndex + MaxMatchListV4Range
+		if last > 0 && q.EndIndex > last {
+			q.EndIndex = last
+		}
+		ml, err := c.GetMatchListV4Context(ctx, accountID, q)
+		if err != nil {
+			return err
+		}
+		if len(ml.Matches) == 0 {
+			return nil
+		}
+		more, err := fn(ml.Matches)
+		if err != nil || !more {
+			return err
+		}
+		if int(ml.EndIndex) <= q.BeginIndex {
+			return nil
+		}
+		q.BeginIndex = int(ml.EndIndex)
+		if q.BeginIndex >= int(ml.TotalGames) || (last > 0 && q.BeginIndex >= last) {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// historyServer serves a match history of n IDs, NA1_<n> being the most recent,
+// paged by the start and count parameters, and records the queries it was sent
+func historyServer(t *testing.T, n int, queries *[]url.Values) *Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		*queries = append(*queries, q)
+		start, _ := strconv.Atoi(q.Get("start"))
+		count, _ := strconv.Atoi(q.Get("count"))
+		matchIDs := []string{}
+		for i := start; i < start+count && i < n; i++ {
+			matchIDs = append(matchIDs, fmt.Sprintf("NA1_%d", n-i))
+		}
+		json.NewEncoder(w).Encode(matchIDs)
+	}))
+	t.Cleanup(server.Close)
+	u, _ := url.Parse(server.URL)
+	return &Client{BaseURL: u, APIKey: "test"}
+}
+
+func TestWalkMatchIDs(t *testing.T) {
+	var queries []url.Values
+	c := historyServer(t, 7, &queries)
+	since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var pages [][]string
+	err := c.WalkMatchIDsContext(context.Background(), "puuid", MatchIDQuery{Count: 3, StartTime: since}, func(matchIDs []string) (bool, error) {
+		pages = append(pages, matchIDs)
+		return true, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The short third page ends the walk without asking for a fourth
+	if len(pages) != 3 || len(pages[0]) != 3 || pages[0][0] != "NA1_7" || len(pages[2]) != 1 || pages[2][0] != "NA1_1" {
+		t.Errorf("pages = %v", pages)
+	}
+	if len(queries) != 3 {
+		t.Fatalf("server saw %d requests, want 3", len(queries))
+	}
+	for i, q := range queries {
+		// Start is left out for the first page
+		if want := []string{"", "3", "6"}[i]; q.Get("start") != want {
+			t.Errorf("request %d start = %q, want %q", i, q.Get("start"), want)
+		}
+		if q.Get("startTime") != strconv.FormatInt(since.Unix(), 10) {
+			t.Errorf("request %d startTime = %q, want it on every page", i, q.Get("startTime"))
+		}
+	}
+
+	// fn returning false stops the walk
+	queries = nil
+	err = c.WalkMatchIDsContext(context.Background(), "puuid", MatchIDQuery{Count: 3}, func(matchIDs []string) (bool, error) {
+		return false, nil
+	})
+	if err != nil || len(queries) != 1 {
+		t.Errorf("walk stopped by fn = %v after %d requests, want nil after 1", err, len(queries))
+	}
+}
@@ -0,0 +1,355 @@
+package client
+
+import (
+	"strconv"
+	"strings"
+)
+
+// MatchReference - MatchReference Object from the Match-V4 API
+type MatchReference struct {
+	Lane       string
+	GameID     int64
+	Champion   int64
+	PlatformID string
+	Season     int64
+	Queue      int64
+	Role       string
+	Timestamp  int64
+}
+
+// Matchlist - Matchlist Object from the Match-V4 API
+type Matchlist struct {
+	Matches    []MatchReference
+	TotalGames int64
+	StartIndex int64
+	EndIndex   int64
+}
+
+// MatchV4 - info regarding a partiular match, as served by the retired Match-V4 API
+type MatchV4 struct {
+	SeasonID              int64
+	QueueID               int64
+	GameID                int64
+	ParticipantIdentities []ParticipantIdentity
+	GameVersion           string
+	PlatformID            string
+	GameMode              string
+	MapID                 int64
+	GameType              string
+	Teams                 []TeamStats
+	Participants          []ParticipantV4
+	GameDuration          int64
+	GameCreation          int64
+}
+
+// ParticipantIdentity - info regarding paricipant identity
+type ParticipantIdentity struct {
+	Player        Player
+	ParticipantID int64
+}
+
+// Player - info regarding player
+type Player struct {
+	CurrentPlatformID string
+	SummonerName      string
+	MatchHistoryURI   string
+	PlatformID        string
+	CurrentAccountID  string
+	ProfileIcon       int
+	SummonerID        string
+	AccountID         string
+}
+
+// TeamStats - info regarding team stats
+type TeamStats struct {
+	FirstDragon          bool
+	FirstInhibitor       bool
+	Bans                 []TeamBans
+	BaronKills           int64
+	FirstRiftHerald      bool
+	FirstBaron           bool
+	RiftHeraldKills      int64
+	FirstBlood           bool
+	TeamID               int64
+	FirstTower           bool
+	VilemawKills         int64
+	InhibitorKills       int64
+	TowerKills           int64
+	DominionVictoryScore int64
+	Win                  string
+	DragonKills          int64
+}
+
+// TeamBans - info regarding team bans
+type TeamBans struct {
+	PickTurn   int64
+	ChampionID int64
+}
+
+// ParticipantV4 - info regarding a Match-V4 participant
+type ParticipantV4 struct {
+	Stats                     ParticipantStats
+	ParticipantID             int64
+	Runes                     []Rune
+	Timeline                  ParticipantTimeline
+	TeamID                    int64
+	Spell2ID                  int64
+	Masteries                 []Mastery
+	HighestAchievedSeasonTier string
+	Spell1ID                  int64
+	ChampionID                int64
+}
+
+// ParticipantStats - info regarding participant stats
+type ParticipantStats struct {
+	FirstBloodAssist                bool
+	VisionScore                     int64
+	MagicDamageDealtToChampions     int64
+	DamageDealtToObjectives         int64
+	TotalTimeCrowdControlDealt      int64
+	LongestTimeSpentLiving          int64
+	Perk1Var1                       int64
+	Perk1Var3                       int64
+	Perk1Var2                       int64
+	TripleKills                     int64
+	Perk3Var3                       int64
+	NodeNeutralizeAssist            int64
+	Perk3Var2                       int64
+	PlayerScore9                    int64
+	PlayerScore8                    int64
+	Kills                           int64
+	PlayerScore1                    int64
+	PlayerScore0                    int64
+	PlayerScore3                    int64
+	PlayerScore2                    int64
+	PlayerScore5                    int64
+	PlayerScore4                    int64
+	PlayerScore7                    int64
+	PlayerScore6                    int64
+	Perk5Var1                       int64
+	Perk5Var3                       int64
+	Perk5Var2                       int64
+	TotalScoreRank                  int64
+	NeutralMinionsKilled            int64
+	DamageDealtToTurrets            int64
+	PhysicalDamageDealtToChampions  int64
+	NodeCapture                     int64
+	LargestMultiKill                int64
+	Perk2Var2                       int64
+	Perk2Var3                       int64
+	TotalUnitsHealed                int64
+	Perk2Var1                       int64
+	Perk4Var1                       int64
+	Perk4Var2                       int64
+	Perk4Var3                       int64
+	WardsKilled                     int64
+	LargestCriticalStrike           int64
+	LargestKillingSpree             int64
+	QuadraKills                     int64
+	TeamObjective                   int64
+	MagicDamageDealt                int64
+	Item2                           int64
+	Item3                           int64
+	Item0                           int64
+	NeutralMinionsKilledTeamJungle  int64
+	Item6                           int64
+	Item4                           int64
+	Item5                           int64
+	Perk1                           int64
+	Perk0                           int64
+	Perk3                           int64
+	Perk2                           int64
+	Perk5                           int64
+	Perk4                           int64
+	Perk3Var1                       int64
+	DamageSelfMitigated             int64
+	MagicalDamageTaken              int64
+	FirstInhibitorKilled            bool
+	TrueDamageTaken                 int64
+	NodeNeutralize                  int64
+	Assists                         int64
+	CombatPlayerScore               int64
+	PerkPrimaryStyle                int64
+	GoldSpent                       int64
+	TrueDamageDealt                 int64
+	ParticipantID                   int64
+	TotalDamageTaken                int64
+	PhysicalDamageDealt             int64
+	SightWardsBoughtInGame          int64
+	TotalDamageDealtToChampions     int64
+	PhysicalDamageTaken             int64
+	TotalPlayerScore                int64
+	Win                             bool
+	ObjectivePlayerScore            int64
+	TotalDamageDealt                int64
+	Item1                           int64
+	NeutralMinionsKilledEnemyJungle int64
+	Deaths                          int64
+	WardsPlaced                     int64
+	PerkSubStyle                    int64
+	TurretKills                     int64
+	FirstBloodKill                  bool
+	TrueDamageDealtToChampions      int64
+	GoldEarned                      int64
+	KillingSprees                   int64
+	UnrealKills                     int64
+	AltersCaptured                  int64
+	FirstTowerAssist                bool
+	FirstTowerKill                  bool
+	ChampLevel                      int64
+	DoubleKills                     int64
+	NodeCaptureAssist               int64
+	InhibitorKills                  int64
+	FirstInhibitorAssist            bool
+	Perk0Var1                       int64
+	Perk0Var2                       int64
+	Perk0Var3                       int64
+	VisionWardsBoughtInGame         int64
+	AltarsNeutralized               int64
+	PentaKills                      int64
+	TotalHeal                       int64
+	TotalMinionsKilled              int64
+	TimeCCingOthers                 int64
+}
+
+// Rune - info regarding a rune
+type Rune struct {
+	RuneID int64
+	Rank   int64
+}
+
+// ParticipantTimeline - info regarding a participant timeline
+type ParticipantTimeline struct {
+	Lane                        string
+	ParticipantID               int64
+	CSDiffPerMinuteDeltas       map[string]float64
+	GoldPerMinDeltas            map[string]float64
+	XPDiffPerMinDeltas          map[string]float64
+	CreepsPerMinDeltas          map[string]float64
+	XPPerMinDeltas              map[string]float64
+	Role                        string
+	DamageTakenDiffPerMinDeltas map[string]float64
+	DamageTakenPerMinDeltas     map[string]float64
+}
+
+// Mastery - info regarding mastery
+type Mastery struct {
+	MasterID int64
+	Rank     int64
+}
+
+// MatchID returns the Match-V5 style ID (e.g. NA1_123) of a Match-V4 match
+func (m *MatchV4) MatchID() string {
+	return strings.ToUpper(m.PlatformID) + "_" + strconv.FormatInt(m.GameID, 10)
+}
+
+// ToV5 converts a Match-V4 match into the Match-V5 model used everywhere else
+func (m *MatchV4) ToV5() Match {
+	players := make(map[int64]Player)
+	for _, pi := range m.ParticipantIdentities {
+		players[pi.ParticipantID] = pi.Player
+	}
+
+	var match Match
+	match.Metadata = MatchMetadata{
+		DataVersion: "v4",
+		MatchID:     m.MatchID(),
+	}
+	match.Info = MatchInfo{
+		GameCreation: m.GameCreation,
+		GameDuration: m.GameDuration,
+		// Match-V4 durations are in seconds; an end timestamp marks them as such
+		GameEndTimestamp: m.GameCreation + m.GameDuration*1000,
+		GameID:           m.GameID,
+		GameMode:         m.GameMode,
+		GameType:         m.GameType,
+		GameVersion:      m.GameVersion,
+		MapID:            m.MapID,
+		PlatformID:       m.PlatformID,
+		QueueID:          m.QueueID,
+	}
+
+	for _, p := range m.Participants {
+		player := players[p.ParticipantID]
+		match.Metadata.Participants = append(match.Metadata.Participants, player.AccountID)
+		match.Info.Participants = append(match.Info.Participants, Participant{
+			ParticipantID:               p.ParticipantID,
+			SummonerID:                  player.SummonerID,
+			SummonerName:                player.SummonerName,
+			AccountID:                   player.AccountID,
+			ProfileIcon:                 int64(player.ProfileIcon),
+			TeamID:                      p.TeamID,
+			ChampionID:                  p.ChampionID,
+			ChampLevel:                  p.Stats.ChampLevel,
+			Summoner1ID:                 p.Spell1ID,
+			Summoner2ID:                 p.Spell2ID,
+			Lane:                        p.Timeline.Lane,
+			Role:                        p.Timeline.Role,
+			TeamPosition:                positionFromLaneRole(p.Timeline.Lane, p.Timeline.Role),
+			Win:                         p.Stats.Win,
+			Kills:                       p.Stats.Kills,
+			Deaths:                      p.Stats.Deaths,
+			Assists:                     p.Stats.Assists,
+			TotalMinionsKilled:          p.Stats.TotalMinionsKilled,
+			NeutralMinionsKilled:        p.Stats.NeutralMinionsKilled,
+			GoldEarned:                  p.Stats.GoldEarned,
+			GoldSpent:                   p.Stats.GoldSpent,
+			TotalDamageDealt:            p.Stats.TotalDamageDealt,
+			TotalDamageDealtToChampions: p.Stats.TotalDamageDealtToChampions,
+			TotalDamageTaken:            p.Stats.TotalDamageTaken,
+			DamageDealtToObjectives:     p.Stats.DamageDealtToObjectives,
+			VisionScore:                 p.Stats.VisionScore,
+			WardsPlaced:                 p.Stats.WardsPlaced,
+			WardsKilled:                 p.Stats.WardsKilled,
+			VisionWardsBoughtInGame:     p.Stats.VisionWardsBoughtInGame,
+			TurretKills:                 p.Stats.TurretKills,
+			FirstBloodKill:              p.Stats.FirstBloodKill,
+			FirstTowerKill:              p.Stats.FirstTowerKill,
+			Item0:                       p.Stats.Item0,
+			Item1:                       p.Stats.Item1,
+			Item2:                       p.Stats.Item2,
+			Item3:                       p.Stats.Item3,
+			Item4:                       p.Stats.Item4,
+			Item5:                       p.Stats.Item5,
+			Item6:                       p.Stats.Item6,
+		})
+	}
+
+	for _, t := range m.Teams {
+		team := Team{
+			TeamID: t.TeamID,
+			Win:    t.Win == "Win",
+			Objectives: Objectives{
+				Baron:      Objective{First: t.FirstBaron, Kills: t.BaronKills},
+				Dragon:     Objective{First: t.FirstDragon, Kills: t.DragonKills},
+				Inhibitor:  Objective{First: t.FirstInhibitor, Kills: t.InhibitorKills},
+				RiftHerald: Objective{First: t.FirstRiftHerald, Kills: t.RiftHeraldKills},
+				Tower:      Objective{First: t.FirstTower, Kills: t.TowerKills},
+				Champion:   Objective{First: t.FirstBlood},
+			},
+		}
+		for _, b := range t.Bans {
+			team.Bans = append(team.Bans, Ban{ChampionID: b.ChampionID, PickTurn: b.PickTurn})
+		}
+		match.Info.Teams = append(match.Info.Teams, team)
+	}
+	return match
+}
+
+// positionFromLaneRole maps the Match-V4 lane/role pair onto a Match-V5 team position
+func positionFromLaneRole(lane, role string) string {
+	switch lane {
+	case "TOP":
+		return "TOP"
+	case "JUNGLE":
+		return "JUNGLE"
+	case "MIDDLE", "MID":
+		return "MIDDLE"
+	case "BOTTOM", "BOT":
+		if role == "DUO_SUPPORT" {
+			return "UTILITY"
+		}
+		return "BOTTOM"
+	}
+	return ""
+}
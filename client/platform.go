@@ -0,0 +1,101 @@
+package client
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Platform - a League of Legends platform (server) such as NA1 or EUW1
+type Platform string
+
+// Region - a regional routing cluster grouping several platforms
+type Region string
+
+// Platforms served by the League API
+const (
+	BR1  Platform = "BR1"
+	EUN1 Platform = "EUN1"
+	EUW1 Platform = "EUW1"
+	JP1  Platform = "JP1"
+	KR   Platform = "KR"
+	LA1  Platform = "LA1"
+	LA2  Platform = "LA2"
+	ME1  Platform = "ME1"
+	NA1  Platform = "NA1"
+	OC1  Platform = "OC1"
+	PH2  Platform = "PH2"
+	RU   Platform = "RU"
+	SG2  Platform = "SG2"
+	TH2  Platform = "TH2"
+	TR1  Platform = "TR1"
+	TW2  Platform = "TW2"
+	VN2  Platform = "VN2"
+)
+
+// Regional routing clusters
+const (
+	Americas Region = "americas"
+	Asia     Region = "asia"
+	Europe   Region = "europe"
+	SEA      Region = "sea"
+)
+
+// platformRegions maps each platform to the cluster serving its match data
+var platformRegions = map[Platform]Region{
+	BR1:  Americas,
+	LA1:  Americas,
+	LA2:  Americas,
+	NA1:  Americas,
+	EUN1: Europe,
+	EUW1: Europe,
+	ME1:  Europe,
+	RU:   Europe,
+	TR1:  Europe,
+	JP1:  Asia,
+	KR:   Asia,
+	OC1:  SEA,
+	PH2:  SEA,
+	SG2:  SEA,
+	TH2:  SEA,
+	TW2:  SEA,
+	VN2:  SEA,
+}
+
+// Platforms returns every known platform
+func Platforms() []Platform {
+	return []Platform{BR1, EUN1, EUW1, JP1, KR, LA1, LA2, ME1, NA1, OC1, PH2, RU, SG2, TH2, TR1, TW2, VN2}
+}
+
+// ParsePlatform parses a platform name case-insensitively (e.g. "euw1")
+func ParsePlatform(name string) (Platform, error) {
+	p := Platform(strings.ToUpper(strings.TrimSpace(name)))
+	if _, ok := platformRegions[p]; !ok {
+		return "", fmt.Errorf("unknown platform %q", name)
+	}
+	return p, nil
+}
+
+// Region returns the regional cluster serving the platform's match data
+func (p Platform) Region() Region {
+	return platformRegions[p]
+}
+
+// AccountRegion returns the regional cluster serving the platform's account data.
+// Account-V1 is not served from SEA, so those platforms use Asia.
+func (p Platform) AccountRegion() Region {
+	if r := p.Region(); r != SEA {
+		return r
+	}
+	return Asia
+}
+
+// URL returns the platform's API host
+func (p Platform) URL() *url.URL {
+	return &url.URL{Scheme: "https", Host: strings.ToLower(string(p)) + ".api.riotgames.com"}
+}
+
+// URL returns the regional cluster's API host
+func (r Region) URL() *url.URL {
+	return &url.URL{Scheme: "https", Host: string(r) + ".api.riotgames.com"}
+}
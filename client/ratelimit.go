@@ -0,0 +1,204 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultAppRateLimit is the development key limit, used until Riot reports the real one
+const defaultAppRateLimit = "20:1,100:120"
+
+// defaultRetryAfter is how long to wait on a 429 that carries no Retry-After header
+const defaultRetryAfter = 1 * time.Second
+
+// rateBucket - a token bucket for one "requests:seconds" window of a Riot rate limit.
+// The window starts with the first request and refills completely when it expires.
+type rateBucket struct {
+	limit  int
+	window time.Duration
+	count  int
+	reset  time.Time
+}
+
+// refill empties the bucket if its window has expired
+func (b *rateBucket) refill(now time.Time) {
+	if !b.reset.IsZero() && !now.Before(b.reset) {
+		b.count = 0
+		b.reset = time.Time{}
+	}
+}
+
+// wait returns how long until the bucket has a token available
+func (b *rateBucket) wait(now time.Time) time.Duration {
+	b.refill(now)
+	if b.count < b.limit {
+		return 0
+	}
+	return b.reset.Sub(now)
+}
+
+// take consumes a token from the bucket
+func (b *rateBucket) take(now time.Time) {
+	if b.reset.IsZero() {
+		b.reset = now.Add(b.window)
+	}
+	b.count++
+}
+
+// rateLimiter - tracks the application limit per host and the method limits per endpoint
+type rateLimiter struct {
+	mu         sync.Mutex
+	buckets    map[string][]*rateBucket
+	retryAfter map[string]time.Time
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{
+		buckets:    make(map[string][]*rateBucket),
+		retryAfter: make(map[string]time.Time),
+	}
+}
+
+// appKey and methodKey name the buckets, application limits being shared across a host
+func appKey(host string) string {
+	return host
+}
+
+func methodKey(host, method string) string {
+	return host + " " + method
+}
+
+// reserve returns how long to wait before a request to the method may be sent,
+// consuming a token from every bucket when no wait is needed
+func (rl *rateLimiter) reserve(host, method string, now time.Time) time.Duration {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	keys := []string{appKey(host), methodKey(host, method)}
+	if _, ok := rl.buckets[keys[0]]; !ok {
+		rl.buckets[keys[0]] = parseRateLimit(defaultAppRateLimit)
+	}
+
+	var wait time.Duration
+	for _, key := range keys {
+		if until, ok := rl.retryAfter[key]; ok {
+			if d := until.Sub(now); d > wait {
+				wait = d
+			} else if d <= 0 {
+				delete(rl.retryAfter, key)
+			}
+		}
+		for _, b := range rl.buckets[key] {
+			if d := b.wait(now); d > wait {
+				wait = d
+			}
+		}
+	}
+	if wait > 0 {
+		return wait
+	}
+	for _, key := range keys {
+		for _, b := range rl.buckets[key] {
+			b.take(now)
+		}
+	}
+	return 0
+}
+
+// wait blocks until a request to the method may be sent, or ctx is done
+func (rl *rateLimiter) wait(ctx context.Context, host, method string) error {
+	for {
+		d := rl.reserve(host, method, time.Now())
+		if d <= 0 {
+			return nil
+		}
+		t := time.NewTimer(d)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// update syncs the buckets with the limits and counts Riot reports on a response
+func (rl *rateLimiter) update(host, method string, header http.Header) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	now := time.Now()
+	rl.sync(appKey(host), header.Get("X-App-Rate-Limit"), header.Get("X-App-Rate-Limit-Count"), now)
+	rl.sync(methodKey(host, method), header.Get("X-Method-Rate-Limit"), header.Get("X-Method-Rate-Limit-Count"), now)
+}
+
+// sync replaces the buckets under key with the reported limits, keeping whichever
+// count is higher between ours and Riot's so concurrent requests are not lost
+func (rl *rateLimiter) sync(key, limits, counts string, now time.Time) {
+	if limits == "" {
+		return
+	}
+	reported := parseRateLimit(limits)
+	current := parseRateLimit(counts)
+	for _, b := range reported {
+		for _, old := range rl.buckets[key] {
+			if old.window == b.window {
+				old.refill(now)
+				b.count = old.count
+				b.reset = old.reset
+			}
+		}
+		for _, c := range current {
+			if c.window == b.window && c.limit > b.count {
+				// Counts are reported in the limit position of the pair
+				b.count = c.limit
+			}
+		}
+		if b.count > 0 && b.reset.IsZero() {
+			b.reset = now.Add(b.window)
+		}
+	}
+	rl.buckets[key] = reported
+}
+
+// backoff blocks further requests after a 429 for as long as Riot asks
+func (rl *rateLimiter) backoff(host, method string, header http.Header) time.Duration {
+	d := defaultRetryAfter
+	if secs, err := strconv.Atoi(header.Get("Retry-After")); err == nil {
+		d = time.Duration(secs) * time.Second
+	}
+
+	key := appKey(host)
+	if t := header.Get("X-Rate-Limit-Type"); t == "method" || t == "service" {
+		key = methodKey(host, method)
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.retryAfter[key] = time.Now().Add(d)
+	return d
+}
+
+// parseRateLimit parses a rate limit header such as "20:1,100:120" into buckets
+func parseRateLimit(header string) []*rateBucket {
+	var buckets []*rateBucket
+	for _, pair := range strings.Split(header, ",") {
+		parts := strings.Split(strings.TrimSpace(pair), ":")
+		if len(parts) != 2 {
+			continue
+		}
+		limit, err := strconv.Atoi(parts[0])
+		if err != nil {
+			continue
+		}
+		secs, err := strconv.Atoi(parts[1])
+		if err != nil {
+			continue
+		}
+		buckets = append(buckets, &rateBucket{limit: limit, window: time.Duration(secs) * time.Second})
+	}
+	return buckets
+}
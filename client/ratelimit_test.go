@@ -0,0 +1,173 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	buckets := parseRateLimit("20:1, 100:120,bad,5:x,:3")
+	if len(buckets) != 2 {
+		t.Fatalf("parsed %d buckets, want 2", len(buckets))
+	}
+	if b := buckets[0]; b.limit != 20 || b.window != time.Second {
+		t.Errorf("bucket 0 = %d:%s, want 20:1s", b.limit, b.window)
+	}
+	if b := buckets[1]; b.limit != 100 || b.window != 120*time.Second {
+		t.Errorf("bucket 1 = %d:%s, want 100:2m0s", b.limit, b.window)
+	}
+	if buckets := parseRateLimit(""); len(buckets) != 0 {
+		t.Errorf("parsed %d buckets from an empty header", len(buckets))
+	}
+}
+
+func TestRateBucket(t *testing.T) {
+	now := time.Now()
+	b := &rateBucket{limit: 2, window: 10 * time.Second}
+	for i := 0; i < 2; i++ {
+		if d := b.wait(now); d != 0 {
+			t.Fatalf("wait before token %d = %s, want 0", i, d)
+		}
+		b.take(now)
+	}
+	if d := b.wait(now.Add(4 * time.Second)); d != 6*time.Second {
+		t.Errorf("wait on a full bucket = %s, want 6s", d)
+	}
+	if d := b.wait(now.Add(10 * time.Second)); d != 0 || b.count != 0 {
+		t.Errorf("wait after the window = %s with count %d, want 0 and 0", d, b.count)
+	}
+}
+
+func TestRateLimiterSync(t *testing.T) {
+	rl := newRateLimiter()
+	now := time.Now()
+	key := methodKey("host", "match-v5.getMatch")
+
+	// Riot's count is taken when it is ahead of ours
+	rl.sync(key, "10:10,100:600", "3:10,1:600", now)
+	buckets := rl.buckets[key]
+	if len(buckets) != 2 || buckets[0].count != 3 || buckets[1].count != 1 {
+		t.Fatalf("buckets after first sync = %+v %+v", buckets[0], buckets[1])
+	}
+	if !buckets[0].reset.Equal(now.Add(10 * time.Second)) {
+		t.Errorf("reset = %s, want the window from now", buckets[0].reset)
+	}
+
+	// Ours is kept when requests Riot hasn't counted yet are in flight
+	for _, b := range rl.buckets[key] {
+		b.count = 7
+	}
+	rl.sync(key, "10:10,100:600", "4:10,2:600", now.Add(time.Second))
+	for _, b := range rl.buckets[key] {
+		if b.count != 7 {
+			t.Errorf("%s bucket count = %d, want 7 kept", b.window, b.count)
+		}
+	}
+
+	// A window that expired starts again from Riot's count
+	rl.sync(key, "10:10,100:600", "1:10,8:600", now.Add(11*time.Second))
+	buckets = rl.buckets[key]
+	if buckets[0].count != 1 || buckets[1].count != 8 {
+		t.Errorf("counts after the 10s window expired = %d, %d, want 1, 8", buckets[0].count, buckets[1].count)
+	}
+
+	// Responses without limits leave the buckets alone
+	rl.sync(key, "", "", now)
+	if len(rl.buckets[key]) != 2 {
+		t.Errorf("sync without limits replaced the buckets")
+	}
+}
+
+func TestRateLimiterUpdate(t *testing.T) {
+	rl := newRateLimiter()
+	header := http.Header{}
+	header.Set("X-App-Rate-Limit", "20:1,100:120")
+	header.Set("X-App-Rate-Limit-Count", "20:1,20:120")
+	header.Set("X-Method-Rate-Limit", "2000:10")
+	header.Set("X-Method-Rate-Limit-Count", "5:10")
+	rl.update("host", "match-v5.getMatch", header)
+
+	if d := rl.reserve("host", "match-v5.getMatch", time.Now()); d <= 0 || d > time.Second {
+		t.Errorf("reserve with the 1s app limit used up = %s, want a wait of up to 1s", d)
+	}
+	if b := rl.buckets[methodKey("host", "match-v5.getMatch")]; len(b) != 1 || b[0].count != 5 {
+		t.Errorf("method buckets = %+v, want one with count 5", b)
+	}
+}
+
+func TestRateLimiterBackoff(t *testing.T) {
+	rl := newRateLimiter()
+	now := time.Now()
+
+	header := http.Header{}
+	header.Set("Retry-After", "3")
+	header.Set("X-Rate-Limit-Type", "method")
+	if d := rl.backoff("host", "match-v5.getMatch", header); d != 3*time.Second {
+		t.Errorf("backoff = %s, want Retry-After's 3s", d)
+	}
+	if d := rl.reserve("host", "match-v5.getMatch", now); d < 2*time.Second || d > 4*time.Second {
+		t.Errorf("reserve on the limited method = %s, want about 3s", d)
+	}
+	// A method limit leaves the other methods of the host alone
+	if d := rl.reserve("host", "account-v1.getByRiotId", now); d != 0 {
+		t.Errorf("reserve on another method = %s, want 0", d)
+	}
+
+	// An application limit blocks the whole host, for defaultRetryAfter without Retry-After
+	header = http.Header{}
+	header.Set("X-Rate-Limit-Type", "application")
+	if d := rl.backoff("host", "match-v5.getMatch", header); d != defaultRetryAfter {
+		t.Errorf("backoff without Retry-After = %s, want %s", d, defaultRetryAfter)
+	}
+	if d := rl.reserve("host", "account-v1.getByRiotId", now); d <= 0 {
+		t.Errorf("reserve on another method after an application limit = %s, want a wait", d)
+	}
+	// The block lifts once it has passed
+	if d := rl.reserve("host", "account-v1.getByRiotId", now.Add(4*time.Second)); d != 0 {
+		t.Errorf("reserve after Retry-After passed = %s, want 0", d)
+	}
+}
+
+func TestRateLimitServer(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-App-Rate-Limit", "2:100")
+		w.Header().Set("X-Method-Rate-Limit", "50:10")
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.Header().Set("X-App-Rate-Limit-Count", "1:100")
+			w.Header().Set("X-Method-Rate-Limit-Count", "1:10")
+			w.Header().Set("X-Rate-Limit-Type", "method")
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`"ok"`))
+	}))
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+	c := &Client{BaseURL: u, APIKey: "test"}
+
+	start := time.Now()
+	body, err := c.LeagueAPIRequest("GET", "match-v5.getMatch", c.platformURL("/lol/match"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != `"ok"` {
+		t.Errorf("body = %s", body)
+	}
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Errorf("server saw %d requests, want the 429 and its retry", n)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %s, before Retry-After's 1s", elapsed)
+	}
+
+	// The 2:100 application limit reported by the server is now used up
+	if d := c.rateLimiter().reserve(u.Host, "match-v5.getMatch", time.Now()); d < 90*time.Second {
+		t.Errorf("reserve after using up the reported limit = %s, want about 100s", d)
+	}
+}
@@ -0,0 +1,81 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"regexp"
+	"strings"
+)
+
+// redacted replaces API keys in output
+const redacted = "RGAPI-REDACTED"
+
+// apiKeyPattern matches Riot API keys, so keys other than the client's own are caught too
+var apiKeyPattern = regexp.MustCompile(`RGAPI-[0-9A-Za-z]{8}-[0-9A-Za-z]{4}-[0-9A-Za-z]{4}-[0-9A-Za-z]{4}-[0-9A-Za-z]{12}`)
+
+// Redact returns s with every Riot API key in it replaced
+func Redact(s string) string {
+	return apiKeyPattern.ReplaceAllString(s, redacted)
+}
+
+// redact returns s with the client's API key and anything that looks like a key replaced,
+// for keys that are malformed and so not caught by Redact alone
+func (c *Client) redact(s string) string {
+	if key := c.apiKey(); len(key) > 0 {
+		s = strings.Replace(s, key, redacted, -1)
+	}
+	return Redact(s)
+}
+
+// redactedError - an error whose message has had API keys removed. The original
+// stays reachable through errors.Is and errors.As.
+type redactedError struct {
+	err error
+	msg string
+}
+
+func (e *redactedError) Error() string {
+	return e.msg
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// redactError returns err with API keys removed from its message
+func (c *Client) redactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := c.redact(err.Error())
+	if msg == err.Error() {
+		return err
+	}
+	return &redactedError{err: err, msg: msg}
+}
+
+// debugRequest logs the request headers to the client's Debug logger, if any
+func (c *Client) debugRequest(req *http.Request) {
+	if c.Debug == nil {
+		return
+	}
+	dump, err := httputil.DumpRequestOut(req, false)
+	if err != nil {
+		c.Debug.Println(c.redact(err.Error()))
+		return
+	}
+	c.Debug.Printf("request:\n%s", c.redact(string(dump)))
+}
+
+// debugResponse logs the response headers and body to the client's Debug logger, if any
+func (c *Client) debugResponse(resp *http.Response, body []byte) {
+	if c.Debug == nil {
+		return
+	}
+	dump, err := httputil.DumpResponse(resp, false)
+	if err != nil {
+		c.Debug.Println(c.redact(err.Error()))
+		return
+	}
+	c.Debug.Printf("response:\n%s%s\n", c.redact(string(dump)), c.redact(string(body)))
+}
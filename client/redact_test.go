@@ -0,0 +1,101 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// testKey is a well-formed key; malformedKey is the client's own key in a shape
+// apiKeyPattern doesn't catch
+const (
+	testKey      = "RGAPI-0123abcd-4567-89ab-cdef-0123456789ab"
+	malformedKey = "RGAPI-not-a-real-key"
+)
+
+// echoServer answers with status and a Riot status body quoting the key it was sent
+func echoServer(t *testing.T, status int) *url.URL {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("X-Riot-Token")
+		w.Header().Set("X-Echo-Token", key)
+		w.WriteHeader(status)
+		w.Write([]byte(`{"status": {"message": "Forbidden: key ` + key + ` is blacklisted", "status_code": 403}}`))
+	}))
+	t.Cleanup(server.Close)
+	u, _ := url.Parse(server.URL)
+	return u
+}
+
+func assertRedacted(t *testing.T, what, output string) {
+	t.Helper()
+	for _, key := range []string{testKey, malformedKey} {
+		if strings.Contains(output, key) {
+			t.Errorf("%s contains the API key %s:\n%s", what, key, output)
+		}
+	}
+}
+
+func TestRedact(t *testing.T) {
+	s := Redact("key " + testKey + " and again " + testKey)
+	assertRedacted(t, "Redact()", s)
+	if strings.Count(s, redacted) != 2 {
+		t.Errorf("Redact() = %q, want both keys replaced", s)
+	}
+}
+
+func TestDebugRedacted(t *testing.T) {
+	for _, key := range []string{testKey, malformedKey} {
+		var buf bytes.Buffer
+		c := &Client{BaseURL: echoServer(t, http.StatusOK), APIKey: key, Debug: log.New(&buf, "", 0)}
+		if _, err := c.LeagueAPIRequest("GET", "match-v5.getMatch", c.platformURL("/lol/match")); err != nil {
+			t.Fatal(err)
+		}
+		assertRedacted(t, "debug log", buf.String())
+		if !strings.Contains(buf.String(), "X-Riot-Token: "+redacted) {
+			t.Errorf("debug log doesn't show the redacted key header:\n%s", buf.String())
+		}
+	}
+}
+
+func TestAPIErrorRedacted(t *testing.T) {
+	for _, key := range []string{testKey, malformedKey} {
+		var buf bytes.Buffer
+		c := &Client{BaseURL: echoServer(t, http.StatusForbidden), APIKey: key, Debug: log.New(&buf, "", 0)}
+		_, err := c.LeagueAPIRequest("GET", "match-v5.getMatch", c.platformURL("/lol/match"))
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("err = %v, want an *APIError", err)
+		}
+		if !strings.Contains(err.Error(), "blacklisted") {
+			t.Errorf("err = %v, want Riot's status message", err)
+		}
+		assertRedacted(t, "API error", err.Error())
+		assertRedacted(t, "debug log", buf.String())
+	}
+}
+
+func TestTransportErrorRedacted(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	u, _ := url.Parse(server.URL)
+	// Nothing listens once the server is closed, so the request fails in transport
+	server.Close()
+
+	for _, key := range []string{testKey, malformedKey} {
+		c := &Client{BaseURL: u, APIKey: key, Retry: RetryPolicy{MaxAttempts: 1}}
+		// A key in the URL, as requests carried it before the X-Riot-Token header
+		_, err := c.LeagueAPIRequest("GET", "match-v5.getMatch", c.platformURL("/lol/match/"+key))
+		if err == nil {
+			t.Fatal("request to a closed server succeeded")
+		}
+		assertRedacted(t, "transport error", err.Error())
+		var urlErr *url.Error
+		if !errors.As(err, &urlErr) {
+			t.Errorf("err = %v, want the *url.Error reachable with errors.As", err)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy - how a request is retried after a transient server error (500, 502,
+// 503 or 504). Only GET requests are retried, since they are safe to repeat.
+type RetryPolicy struct {
+	// MaxAttempts is how many times a request is sent at most, counting the first;
+	// 1 disables retries
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry, doubled for each one after it
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between attempts
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy is used for the fields of Client.Retry left at zero
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    10 * time.Second,
+}
+
+// withDefaults fills the fields left at zero from DefaultRetryPolicy
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultRetryPolicy.MaxAttempts
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = DefaultRetryPolicy.BaseDelay
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = DefaultRetryPolicy.MaxDelay
+	}
+	return p
+}
+
+// shouldRetry reports whether a request that failed with status on the given
+// attempt (0 for the first) may be sent again
+func (p RetryPolicy) shouldRetry(method string, status, attempt int) bool {
+	if method != http.MethodGet || attempt+1 >= p.MaxAttempts {
+		return false
+	}
+	return transientStatus(status)
+}
+
+// delay returns how long to wait before retrying after the given attempt: a random
+// duration between half and all of the exponential backoff, so concurrent requests
+// spread out
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	d := p.BaseDelay
+	for i := 0; i < attempt && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	if d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// sleep waits for d, or returns ctx's error if it is done first
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
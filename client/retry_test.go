@@ -0,0 +1,191 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyServer answers every request with status, counting the requests
+func flakyServer(t *testing.T, status int, requests *int32) *Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		w.WriteHeader(status)
+		w.Write([]byte(`{"status": {"message": "` + http.StatusText(status) + `", "status_code": ` + strconv.Itoa(status) + `}}`))
+	}))
+	t.Cleanup(server.Close)
+	u, _ := url.Parse(server.URL)
+	return &Client{
+		BaseURL: u,
+		APIKey:  "test",
+		Retry:   RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond},
+	}
+}
+
+func TestRetryServerErrors(t *testing.T) {
+	var requests int32
+	c := flakyServer(t, http.StatusServiceUnavailable, &requests)
+
+	_, err := c.LeagueAPIRequest("GET", "match-v5.getMatch", c.platformURL("/lol/match"))
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("err = %v, want an *APIError", err)
+	}
+	if apiErr.StatusCode != http.StatusServiceUnavailable || !apiErr.Retryable {
+		t.Errorf("APIError = %+v, want a retryable 503", apiErr)
+	}
+	if !errors.Is(err, ErrServiceUnavailable) {
+		t.Errorf("err = %v, want ErrServiceUnavailable", err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 3 {
+		t.Errorf("server saw %d requests, want MaxAttempts = 3", n)
+	}
+}
+
+func TestRetryRecovers(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`"ok"`))
+	}))
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+	c := &Client{BaseURL: u, APIKey: "test", Retry: RetryPolicy{BaseDelay: time.Millisecond}}
+
+	body, err := c.LeagueAPIRequest("GET", "match-v5.getMatch", c.platformURL("/lol/match"))
+	if err != nil || string(body) != `"ok"` {
+		t.Errorf("LeagueAPIRequest() = %s, %v, want the third attempt's body", body, err)
+	}
+}
+
+func TestNoRetryClientErrors(t *testing.T) {
+	for _, status := range []int{http.StatusBadRequest, http.StatusNotFound, http.StatusNotImplemented, http.StatusHTTPVersionNotSupported} {
+		var requests int32
+		c := flakyServer(t, status, &requests)
+		_, err := c.LeagueAPIRequest("GET", "match-v5.getMatch", c.platformURL("/lol/match"))
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != status || apiErr.Retryable {
+			t.Errorf("%d: err = %v, want a non-retryable *APIError", status, err)
+		}
+		if n := atomic.LoadInt32(&requests); n != 1 {
+			t.Errorf("%d: server saw %d requests, want 1", status, n)
+		}
+	}
+}
+
+func TestNoRetryPost(t *testing.T) {
+	var requests int32
+	c := flakyServer(t, http.StatusServiceUnavailable, &requests)
+	if _, err := c.LeagueAPIRequest("POST", "tournament-v5.createCode", c.platformURL("/lol/tournament")); err == nil {
+		t.Fatal("POST to a failing server succeeded")
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("server saw %d POSTs, want 1", n)
+	}
+}
+
+func TestRetryCancelledBackoff(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		// Cancel once the attempt has failed and the client is backing off
+		time.AfterFunc(50*time.Millisecond, cancel)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+	c := &Client{BaseURL: u, APIKey: "test", Retry: RetryPolicy{BaseDelay: time.Minute, MaxDelay: time.Minute}}
+
+	start := time.Now()
+	_, err := c.LeagueAPIRequestContext(ctx, "GET", "match-v5.getMatch", c.platformURL("/lol/match"))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("returned after %s, want the backoff sleep cut short", elapsed)
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("server saw %d requests, want 1", n)
+	}
+}
+
+func TestAPIErrorRetryable(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 2}
+	for status := 400; status < 600; status++ {
+		apiErr := newAPIError("match-v5.getMatch", status, nil)
+		retried := p.shouldRetry("GET", status, 0)
+		// Rate limits are waited out rather than retried by the policy
+		if apiErr.Retryable != (retried || status == http.StatusTooManyRequests) {
+			t.Errorf("%d: Retryable = %t, but the retry policy retries it: %t", status, apiErr.Retryable, retried)
+		}
+		if unavailable := errors.Is(apiErr, ErrServiceUnavailable); unavailable != retried {
+			t.Errorf("%d: ErrServiceUnavailable = %t, but the retry policy retries it: %t", status, unavailable, retried)
+		}
+	}
+}
+
+func TestOnUnauthorized(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.Header.Get("X-Riot-Token") != "fresh" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`"ok"`))
+	}))
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+
+	for _, tc := range []struct {
+		newKey string
+		ok     bool
+	}{
+		{"fresh", true},
+		// A key that is rejected too isn't refreshed again
+		{"also-expired", false},
+	} {
+		atomic.StoreInt32(&requests, 0)
+		refreshes := 0
+		c := &Client{BaseURL: u, APIKey: "expired", OnUnauthorized: func() (string, error) {
+			refreshes++
+			return tc.newKey, nil
+		}}
+		body, err := c.LeagueAPIRequest("GET", "match-v5.getMatch", c.platformURL("/lol/match"))
+		if tc.ok && (err != nil || string(body) != `"ok"`) {
+			t.Errorf("%s: LeagueAPIRequest() = %s, %v, want the body sent for the new key", tc.newKey, body, err)
+		}
+		if !tc.ok && !errors.Is(err, ErrUnauthorized) {
+			t.Errorf("%s: err = %v, want ErrUnauthorized", tc.newKey, err)
+		}
+		if n := atomic.LoadInt32(&requests); refreshes != 1 || n != 2 {
+			t.Errorf("%s: %d refreshes and %d requests, want 1 and 2", tc.newKey, refreshes, n)
+		}
+		if c.APIKey != tc.newKey {
+			t.Errorf("%s: key after refresh = %q", tc.newKey, c.APIKey)
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	for attempt, max := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		max *= time.Millisecond
+		for i := 0; i < 20; i++ {
+			if d := p.delay(attempt); d < max/2 || d > max {
+				t.Fatalf("delay(%d) = %s, want between %s and %s", attempt, d, max/2, max)
+			}
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// CurrentGameInfo - CurrentGameInfo Object from the Spectator API: a game in progress
+type CurrentGameInfo struct {
+	GameID     int64
+	GameType   string
+	GameMode   string
+	MapID      int64
+	PlatformID string
+	// GameQueueConfigID is the queue ID of the game
+	GameQueueConfigID int64
+	// GameStartTime is in milliseconds since the epoch, and GameLength in seconds
+	GameStartTime   int64
+	GameLength      int64
+	BannedChampions []BannedChampion
+	Participants    []CurrentGameParticipant
+}
+
+// BannedChampion - a champion banned in a game in progress
+type BannedChampion struct {
+	ChampionID int64
+	TeamID     int64
+	PickTurn   int64
+}
+
+// CurrentGameParticipant - a player in a game in progress
+type CurrentGameParticipant struct {
+	PuuID      string
+	SummonerID string
+	// RiotID is Name#TAG; Spectator-V4 only gives SummonerName
+	RiotID        string
+	SummonerName  string
+	TeamID        int64
+	ChampionID    int64
+	Spell1ID      int64
+	Spell2ID      int64
+	ProfileIconID int64
+	Bot           bool
+}
+
+// Name returns the participant's Riot ID, or their summoner name if it isn't known
+func (p *CurrentGameParticipant) Name() string {
+	if len(p.RiotID) > 0 {
+		return p.RiotID
+	}
+	return p.SummonerName
+}
+
+// Participant returns the player with the given PUUID, or false if they aren't in the game
+func (g *CurrentGameInfo) Participant(puuid string) (CurrentGameParticipant, bool) {
+	for _, p := range g.Participants {
+		if p.PuuID == puuid {
+			return p, true
+		}
+	}
+	return CurrentGameParticipant{}, false
+}
+
+// GetActiveGame - Gets the game a player is currently in. ErrNotFound means they aren't in one.
+func (c *Client) GetActiveGame(puuid string) (*CurrentGameInfo, error) {
+	return c.GetActiveGameContext(context.Background(), puuid)
+}
+
+// GetActiveGameContext - GetActiveGame with a context for cancellation and deadlines
+func (c *Client) GetActiveGameContext(ctx context.Context, puuid string) (*CurrentGameInfo, error) {
+	// Creating the url
+	u := c.platformURL("/lol/spectator/v5/active-games/by-summoner/" + puuid)
+
+	body, err := c.LeagueAPIRequestContext(ctx, "GET", "spectator-v5.getCurrentGameInfoByPuuid", u)
+	if err != nil {
+		return nil, err
+	}
+	var g CurrentGameInfo
+	err = json.Unmarshal(body, &g)
+	if err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// GetActiveGameV4 - Gets the game a summoner is currently in from the retired
+// Spectator-V4 API, by encrypted summoner ID
+func (c *Client) GetActiveGameV4(summonerID string) (*CurrentGameInfo, error) {
+	return c.GetActiveGameV4Context(context.Background(), summonerID)
+}
+
+// GetActiveGameV4Context - GetActiveGameV4 with a context for cancellation and deadlines
+func (c *Client) GetActiveGameV4Context(ctx context.Context, summonerID string) (*CurrentGameInfo, error) {
+	// Creating the url
+	u := c.platformURL("/lol/spectator/v4/active-games/by-summoner/" + summonerID)
+
+	body, err := c.LeagueAPIRequestContext(ctx, "GET", "spectator-v4.getCurrentGameInfoBySummoner", u)
+	if err != nil {
+		return nil, err
+	}
+	var g CurrentGameInfo
+	err = json.Unmarshal(body, &g)
+	if err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
@@ -0,0 +1,191 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+)
+
+// Timeline event types used by the laning analytics
+const (
+	EventChampionKill = "CHAMPION_KILL"
+	EventBuildingKill = "BUILDING_KILL"
+)
+
+// Timeline - the frame-by-frame record of a match, as served by the Match-V5 API
+type Timeline struct {
+	Metadata MatchMetadata
+	Info     TimelineInfo
+}
+
+// TimelineInfo - the frames of a match timeline
+type TimelineInfo struct {
+	GameID int64
+	// FrameInterval is the time between frames in milliseconds, usually a minute
+	FrameInterval int64
+	Frames        []Frame
+	Participants  []TimelineParticipant
+}
+
+// TimelineParticipant - maps a participant ID of the timeline onto a player
+type TimelineParticipant struct {
+	ParticipantID int64
+	PuuID         string
+}
+
+// Frame - the state of every participant at one point of a match, and the events since
+// the previous frame
+type Frame struct {
+	// Timestamp is the time into the game in milliseconds
+	Timestamp int64
+	// ParticipantFrames are keyed by participant ID, as a string
+	ParticipantFrames map[string]ParticipantFrame
+	Events            []Event
+}
+
+// ParticipantFrame - a participant's state at a frame
+type ParticipantFrame struct {
+	ParticipantID       int64
+	Position            Position
+	CurrentGold         int64
+	TotalGold           int64
+	Level               int64
+	XP                  int64
+	MinionsKilled       int64
+	JungleMinionsKilled int64
+}
+
+// Position - a point on the map
+type Position struct {
+	X int64
+	Y int64
+}
+
+// Event - something that happened during a match. Which fields are set depends on Type.
+type Event struct {
+	Type string
+	// Timestamp is the time into the game in milliseconds
+	Timestamp               int64
+	ParticipantID           int64
+	KillerID                int64
+	VictimID                int64
+	AssistingParticipantIDs []int64
+	// Position is where champion and monster kills happened
+	Position *Position
+	// TeamID is the team that lost the building of a BUILDING_KILL
+	TeamID       int64
+	KillerTeamID int64
+	BuildingType string
+	LaneType     string
+	TowerType    string
+	MonsterType  string
+	KillType     string
+	WardType     string
+	ItemID       int64
+}
+
+// ParticipantFrame returns a participant's state at the frame
+func (f *Frame) ParticipantFrame(participantID int64) (ParticipantFrame, bool) {
+	pf, ok := f.ParticipantFrames[strconv.FormatInt(participantID, 10)]
+	return pf, ok
+}
+
+// FrameAt returns the frame closest to the given time into the game, in milliseconds,
+// or false if the game ended before it. Frames are taken slightly after each interval,
+// so the frame for 10:00 may be stamped 10:00.012.
+func (t *Timeline) FrameAt(timestamp int64) (Frame, bool) {
+	frames := t.Info.Frames
+	if len(frames) == 0 || frames[len(frames)-1].Timestamp < timestamp {
+		return Frame{}, false
+	}
+	closest := frames[0]
+	for _, f := range frames[1:] {
+		if abs(f.Timestamp-timestamp) < abs(closest.Timestamp-timestamp) {
+			closest = f
+		}
+	}
+	return closest, true
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// Events returns the events of every frame of the type, in order
+func (t *Timeline) Events(eventType string) []Event {
+	var events []Event
+	for _, frame := range t.Info.Frames {
+		for _, event := range frame.Events {
+			if event.Type == eventType {
+				events = append(events, event)
+			}
+		}
+	}
+	return events
+}
+
+// MatchTimelineV4 - the frame-by-frame record of a match, as served by the retired
+// Match-V4 API. Its frames have the same shape as Match-V5's.
+type MatchTimelineV4 struct {
+	FrameInterval int64
+	Frames        []Frame
+}
+
+// ToV5 converts a Match-V4 timeline of the match into the Match-V5 model
+func (t *MatchTimelineV4) ToV5(matchID string) Timeline {
+	var timeline Timeline
+	timeline.Metadata = MatchMetadata{
+		DataVersion: "v4",
+		MatchID:     matchID,
+	}
+	timeline.Info = TimelineInfo{
+		FrameInterval: t.FrameInterval,
+		Frames:        t.Frames,
+	}
+	return timeline
+}
+
+// GetMatchTimeline - gets the timeline of a match
+func (c *Client) GetMatchTimeline(matchID string) (*Timeline, error) {
+	return c.GetMatchTimelineContext(context.Background(), matchID)
+}
+
+// GetMatchTimelineContext - GetMatchTimeline with a context for cancellation and deadlines
+func (c *Client) GetMatchTimelineContext(ctx context.Context, matchID string) (*Timeline, error) {
+	// Creating the url
+	u := c.regionalURL(c.Platform.Region(), "/lol/match/v5/matches/"+matchID+"/timeline")
+	body, err := c.LeagueAPIRequestContext(ctx, "GET", "match-v5.getTimeline", u)
+	if err != nil {
+		return nil, err
+	}
+	var t Timeline
+	err = json.Unmarshal(body, &t)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// GetMatchTimelineV4 - gets the timeline of a match from the retired Match-V4 API
+func (c *Client) GetMatchTimelineV4(gameID int64) (*MatchTimelineV4, error) {
+	return c.GetMatchTimelineV4Context(context.Background(), gameID)
+}
+
+// GetMatchTimelineV4Context - GetMatchTimelineV4 with a context for cancellation and deadlines
+func (c *Client) GetMatchTimelineV4Context(ctx context.Context, gameID int64) (*MatchTimelineV4, error) {
+	// Creating the url
+	u := c.platformURL("/lol/match/v4/timelines/by-match/" + strconv.FormatInt(gameID, 10))
+	body, err := c.LeagueAPIRequestContext(ctx, "GET", "match-v4.getMatchTimeline", u)
+	if err != nil {
+		return nil, err
+	}
+	var t MatchTimelineV4
+	err = json.Unmarshal(body, &t)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFrameAt(t *testing.T) {
+	timeline := Timeline{Info: TimelineInfo{Frames: []Frame{
+		{Timestamp: 0}, {Timestamp: 60017}, {Timestamp: 120004}, {Timestamp: 150000},
+	}}}
+	cases := []struct {
+		at, want int64
+		ok       bool
+	}{
+		{0, 0, true},
+		// Frames are stamped slightly after each minute
+		{60000, 60017, true},
+		{120000, 120004, true},
+		// The game ended at 2:30, with a frame taken then
+		{150000, 150000, true},
+		{150001, 0, false},
+		{180000, 0, false},
+	}
+	for _, tc := range cases {
+		frame, ok := timeline.FrameAt(tc.at)
+		if ok != tc.ok || (ok && frame.Timestamp != tc.want) {
+			t.Errorf("FrameAt(%d) = %d, %t, want %d, %t", tc.at, frame.Timestamp, ok, tc.want, tc.ok)
+		}
+	}
+	if _, ok := (&Timeline{}).FrameAt(0); ok {
+		t.Error("FrameAt(0) of a timeline without frames succeeded")
+	}
+}
+
+func TestMatchTimelineV4ToV5(t *testing.T) {
+	b, err := ioutil.ReadFile(filepath.Join("testdata", "timeline_v4.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v4 MatchTimelineV4
+	if err = json.Unmarshal(b, &v4); err != nil {
+		t.Fatal(err)
+	}
+	timeline := v4.ToV5("NA1_100")
+	if timeline.Metadata.MatchID != "NA1_100" || timeline.Metadata.DataVersion != "v4" || timeline.Info.FrameInterval != 60000 {
+		t.Errorf("converted timeline = %+v", timeline.Metadata)
+	}
+	frame, ok := timeline.FrameAt(60000)
+	if !ok {
+		t.Fatal("no frame at 1:00")
+	}
+	if pf, ok := frame.ParticipantFrame(1); !ok || pf.TotalGold != 620 || pf.Position != (Position{6100, 6200}) {
+		t.Errorf("participant frame at 1:00 = %+v, %t", pf, ok)
+	}
+	kills := timeline.Events(EventChampionKill)
+	if len(kills) != 1 || kills[0].KillerID != 1 || kills[0].Position == nil || kills[0].AssistingParticipantIDs[0] != 2 {
+		t.Errorf("champion kills = %+v", kills)
+	}
+
+	// The converted timeline survives being stored and read back
+	b, err = json.Marshal(timeline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stored Timeline
+	if err = json.Unmarshal(b, &stored); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(stored, timeline) {
+		t.Errorf("timeline after a JSON round trip = %+v, want %+v", stored, timeline)
+	}
+}
@@ -3,18 +3,28 @@ package config
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
-	"os/user"
 	"regexp"
 	"strings"
+
+	"github.com/WhiteAcres/leaguestats/paths"
 )
 
+// DefaultPlatform is the platform used when none is configured
+const DefaultPlatform = "NA1"
+
 // Conf - Config Object
 type Conf struct {
 	APIKey string
+	// Platform is the default platform (e.g. NA1, EUW1, KR) summoners are looked up on
+	Platform string
+	// MaxAttempts is how many times a request failing with a server error is sent,
+	// counting the first; the client default if zero
+	MaxAttempts int `json:",omitempty"`
 }
 
 func validKey(apiKey string) bool {
@@ -30,31 +40,8 @@ func validSummonerName(name string) bool {
 	return len(name) > 0
 }
 
-func createDirIfNotExist(dir string) {
-	if _, err := os.Stat(dir); os.IsNotExist(err) {
-		err = os.MkdirAll(dir, 0755)
-		if err != nil {
-			log.Fatal(err)
-		}
-	}
-}
-
-func getConfigFile() *os.File {
-	// Create the conf directory if not exists
-	user, err := user.Current()
-	if err != nil {
-		log.Fatal(err)
-	}
-	confDirPath := user.HomeDir + "\\AppData\\Local\\leaguestats"
-	createDirIfNotExist(confDirPath)
-
-	// Read the conf file and create if it doesn't exist
-	confFilePath := confDirPath + "\\conf.json"
-	f, err := os.OpenFile(confFilePath, os.O_RDONLY|os.O_CREATE, 0666)
-	if err != nil {
-		log.Fatal(err)
-	}
-	return f
+func getConfigFile() string {
+	return paths.ConfigFile("conf.json")
 }
 
 // GetNewAPIKey is a public method for requesting new api key from user
@@ -74,10 +61,8 @@ func GetNewAPIKey(message string) string {
 
 // LoadConfig initializes the config
 func LoadConfig() *Conf {
-	f := getConfigFile()
-	defer f.Close()
-	b, err := ioutil.ReadAll(f)
-	if err != nil {
+	b, err := ioutil.ReadFile(getConfigFile())
+	if err != nil && !os.IsNotExist(err) {
 		log.Fatal(err)
 	}
 	var conf Conf
@@ -94,6 +79,8 @@ func UpdateConfig(updates map[string]string) {
 	for k, v := range updates {
 		if k == "APIKey" {
 			c.APIKey = v
+		} else if k == "Platform" {
+			c.Platform = v
 		}
 	}
 	c.SaveConfig()
@@ -105,18 +92,34 @@ func (c *Conf) SaveConfig() {
 	if err != nil {
 		log.Fatal(err)
 	}
-	f := getConfigFile()
-	defer f.Close()
-	_, err = f.Write(fileData)
+	err = ioutil.WriteFile(getConfigFile(), fileData, 0600)
 	if err != nil {
 		log.Fatal(err)
 	}
 }
 
+// HasValidKey checks if the config holds a well-formed API key
+func (c *Conf) HasValidKey() bool {
+	return validKey(c.APIKey)
+}
+
+// SetAPIKey validates and saves a new API key
+func (c *Conf) SetAPIKey(apiKey string) error {
+	if !validKey(apiKey) {
+		return errors.New("API Key is invalid")
+	}
+	c.APIKey = apiKey
+	c.SaveConfig()
+	return nil
+}
+
 // ValidateConfig validates the config, updating the conf file if necessary
 func (c *Conf) ValidateConfig() {
 	if validKey(c.APIKey) == false {
 		c.APIKey = GetNewAPIKey("API Key is invalid")
 	}
+	if len(c.Platform) == 0 {
+		c.Platform = DefaultPlatform
+	}
 	c.SaveConfig()
 }
@@ -0,0 +1,174 @@
+package ddragon
+
+import (
+	"strconv"
+)
+
+// Image - the sprite and icon of a champion, item or spell
+type Image struct {
+	Full   string
+	Sprite string
+	Group  string
+}
+
+// Champion - a champion from champion.json
+type Champion struct {
+	// ID is the champion's name in file names, e.g. MonkeyKing
+	ID string
+	// Key is the numeric champion ID used by the League API, as a string
+	Key   string
+	Name  string
+	Title string
+	Blurb string
+	Tags  []string
+	Image Image
+}
+
+// ChampionData - the contents of champion.json, keyed by Champion.ID
+type ChampionData struct {
+	Version string
+	Data    map[string]Champion
+}
+
+// Item - an item from item.json
+type Item struct {
+	Name        string
+	Description string
+	Plaintext   string
+	Gold        ItemGold
+	Tags        []string
+	Image       Image
+}
+
+// ItemGold - what an item costs and sells for
+type ItemGold struct {
+	Base        int64
+	Total       int64
+	Sell        int64
+	Purchasable bool
+}
+
+// ItemData - the contents of item.json, keyed by item ID
+type ItemData struct {
+	Version string
+	Data    map[string]Item
+}
+
+// SummonerSpell - a summoner spell from summoner.json
+type SummonerSpell struct {
+	ID string
+	// Key is the numeric spell ID used by the League API, as a string
+	Key         string
+	Name        string
+	Description string
+	Cooldown    []float64
+	Modes       []string
+	Image       Image
+}
+
+// SummonerSpellData - the contents of summoner.json, keyed by SummonerSpell.ID
+type SummonerSpellData struct {
+	Version string
+	Data    map[string]SummonerSpell
+}
+
+// RuneTree - a rune path from runesReforged.json, e.g. Precision
+type RuneTree struct {
+	ID    int64
+	Key   string
+	Icon  string
+	Name  string
+	Slots []RuneSlot
+}
+
+// RuneSlot - a row of runes in a tree, of which one is picked
+type RuneSlot struct {
+	Runes []Rune
+}
+
+// Rune - a rune from runesReforged.json
+type Rune struct {
+	ID        int64
+	Key       string
+	Icon      string
+	Name      string
+	ShortDesc string
+	LongDesc  string
+}
+
+// Champions returns the champions of a Data Dragon version
+func (c *Client) Champions(version string) (*ChampionData, error) {
+	var cd ChampionData
+	if err := c.loadData(version, "champion.json", &cd); err != nil {
+		return nil, err
+	}
+	return &cd, nil
+}
+
+// Items returns the items of a Data Dragon version
+func (c *Client) Items(version string) (*ItemData, error) {
+	var id ItemData
+	if err := c.loadData(version, "item.json", &id); err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+// SummonerSpells returns the summoner spells of a Data Dragon version
+func (c *Client) SummonerSpells(version string) (*SummonerSpellData, error) {
+	var sd SummonerSpellData
+	if err := c.loadData(version, "summoner.json", &sd); err != nil {
+		return nil, err
+	}
+	return &sd, nil
+}
+
+// RuneTrees returns the rune trees of a Data Dragon version
+func (c *Client) RuneTrees(version string) ([]RuneTree, error) {
+	var trees []RuneTree
+	if err := c.loadData(version, "runesReforged.json", &trees); err != nil {
+		return nil, err
+	}
+	return trees, nil
+}
+
+// ByKey returns the champion with the numeric ID used by the League API
+func (cd *ChampionData) ByKey(championID int64) (Champion, bool) {
+	key := strconv.FormatInt(championID, 10)
+	for _, champion := range cd.Data {
+		if champion.Key == key {
+			return champion, true
+		}
+	}
+	return Champion{}, false
+}
+
+// Names returns the champion names keyed by the numeric IDs used by the League API
+func (cd *ChampionData) Names() map[int64]string {
+	names := make(map[int64]string)
+	for _, champion := range cd.Data {
+		championID, err := strconv.ParseInt(champion.Key, 10, 64)
+		if err != nil {
+			continue
+		}
+		names[championID] = champion.Name
+	}
+	return names
+}
+
+// ByID returns the item with the ID used by the League API (e.g. Participant.Item0)
+func (id *ItemData) ByID(itemID int64) (Item, bool) {
+	item, ok := id.Data[strconv.FormatInt(itemID, 10)]
+	return item, ok
+}
+
+// ByKey returns the summoner spell with the numeric ID used by the League API
+func (sd *SummonerSpellData) ByKey(spellID int64) (SummonerSpell, bool) {
+	key := strconv.FormatInt(spellID, 10)
+	for _, spell := range sd.Data {
+		if spell.Key == key {
+			return spell, true
+		}
+	}
+	return SummonerSpell{}, false
+}
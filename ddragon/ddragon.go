@@ -0,0 +1,259 @@
+package ddragon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/WhiteAcres/leaguestats/patch"
+	"github.com/WhiteAcres/leaguestats/paths"
+)
+
+// DefaultBaseURL is the Data Dragon CDN
+const DefaultBaseURL = "https://ddragon.leagueoflegends.com"
+
+// DefaultLocale is the language data is downloaded in when Client.Locale is empty
+const DefaultLocale = "en_US"
+
+// DefaultVersionsTTL is how long a downloaded versions.json is used when Client.VersionsTTL is zero
+const DefaultVersionsTTL = 6 * time.Hour
+
+// ErrNotCached is returned when a file can be neither downloaded nor read from the cache
+var ErrNotCached = errors.New("Data Dragon file not cached")
+
+// Client - Data Dragon client that keeps every file it downloads on disk. Files of
+// a patch never change once published, so they are only downloaded once; the list
+// of versions is refreshed once it is older than VersionsTTL, and Data Dragon can be
+// reached. Lookups are served from the cache when it can't be, so they work offline
+// for any patch seen before.
+type Client struct {
+	// BaseURL, when set, overrides the Data Dragon CDN
+	BaseURL *url.URL
+	// CacheDir is the directory files are cached in, laid out like the CDN
+	// (versions.json and VERSION/data/LOCALE/FILE.json). A directory of fixture
+	// files in that layout can be used directly.
+	CacheDir string
+	// Locale is the language of names and descriptions; DefaultLocale if empty
+	Locale     string
+	HTTPClient *http.Client
+	// Offline, when set, only reads from the cache
+	Offline bool
+	// VersionsTTL is how long versions.json is used before it is downloaded again;
+	// DefaultVersionsTTL if zero
+	VersionsTTL time.Duration
+
+	// versions is the list last loaded and versionsErr why it couldn't be, kept
+	// until versionsAt is VersionsTTL ago so each is only tried once in that time
+	versionsMu  sync.Mutex
+	versions    []string
+	versionsErr error
+	versionsAt  time.Time
+}
+
+// New returns a client caching files in cacheDir
+func New(cacheDir string) *Client {
+	return &Client{
+		CacheDir:   cacheDir,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+var (
+	defaultOnce   sync.Once
+	defaultClient *Client
+)
+
+// Default returns the shared client, caching in the ddragon directory of paths.DataDir
+func Default() *Client {
+	defaultOnce.Do(func() {
+		if defaultClient == nil {
+			defaultClient = New(filepath.Join(paths.DataDir(), "ddragon"))
+		}
+	})
+	return defaultClient
+}
+
+// SetDefault replaces the shared client returned by Default (e.g. with one reading fixtures)
+func SetDefault(c *Client) {
+	defaultOnce.Do(func() {})
+	defaultClient = c
+}
+
+func (c *Client) locale() string {
+	if len(c.Locale) == 0 {
+		return DefaultLocale
+	}
+	return c.Locale
+}
+
+func (c *Client) versionsTTL() time.Duration {
+	if c.VersionsTTL <= 0 {
+		return DefaultVersionsTTL
+	}
+	return c.VersionsTTL
+}
+
+// Versions returns every Data Dragon version, newest first. The list is downloaded
+// again once the cached one is VersionsTTL old, and kept in memory for as long, so
+// lookups reach the network at most once in that time however many are made.
+func (c *Client) Versions() ([]string, error) {
+	c.versionsMu.Lock()
+	defer c.versionsMu.Unlock()
+	if !c.versionsAt.IsZero() && time.Since(c.versionsAt) < c.versionsTTL() {
+		return c.versions, c.versionsErr
+	}
+
+	refresh := true
+	if info, err := os.Stat(c.cacheFile("versions.json")); err == nil {
+		refresh = time.Since(info.ModTime()) >= c.versionsTTL()
+	}
+	var versions []string
+	err := c.load("versions.json", "api/versions.json", refresh, &versions)
+	if err != nil {
+		versions = nil
+	}
+	c.versions, c.versionsErr, c.versionsAt = versions, err, time.Now()
+	return versions, err
+}
+
+// LatestVersion returns the newest Data Dragon version
+func (c *Client) LatestVersion() (string, error) {
+	versions, err := c.Versions()
+	if err != nil {
+		return "", err
+	}
+	if len(versions) == 0 {
+		return "", errors.New("Data Dragon lists no versions")
+	}
+	return versions[0], nil
+}
+
+// VersionFor returns the Data Dragon version for the patch of a match's game version
+// (e.g. 14.3.1 for 14.3.556.1234). A patch that isn't listed gets the newest version
+// before it, and a game version that can't be parsed gets the newest version.
+func (c *Client) VersionFor(gameVersion string) (string, error) {
+	versions, err := c.Versions()
+	if err != nil {
+		return "", err
+	}
+	var newest, best string
+	var newestPatch, bestPatch patch.Patch
+	target, targetErr := patch.Parse(gameVersion)
+	for _, version := range versions {
+		// Skip the oldest versions, named like lolpatch_3.7
+		p, err := patch.Parse(version)
+		if err != nil {
+			continue
+		}
+		if len(newest) == 0 || newestPatch.Less(p) {
+			newest, newestPatch = version, p
+		}
+		if targetErr == nil && !target.Bucket().Less(p.Bucket()) && (len(best) == 0 || bestPatch.Less(p)) {
+			best, bestPatch = version, p
+		}
+	}
+	if len(best) > 0 {
+		return best, nil
+	}
+	if len(newest) == 0 {
+		return "", errors.New("Data Dragon lists no versions")
+	}
+	return newest, nil
+}
+
+// Seed writes a file into the cache, as if it had been downloaded for the version
+// and the client's locale, so lookups of it work without the network
+func (c *Client) Seed(version, name string, data []byte) error {
+	return c.writeCache(c.dataPath(version, name), data)
+}
+
+// dataPath returns the path of a data file relative to the CDN and the cache
+func (c *Client) dataPath(version, name string) string {
+	return version + "/data/" + c.locale() + "/" + name
+}
+
+// loadData reads a data file of a patch into v, downloading it if it isn't cached
+func (c *Client) loadData(version, name string, v interface{}) error {
+	path := c.dataPath(version, name)
+	return c.load(path, "cdn/"+path, false, v)
+}
+
+// load reads the cached file at path into v. Files not cached, or always refreshed
+// ones, are downloaded from cdnPath first; a failed download falls back to the cache.
+func (c *Client) load(path, cdnPath string, refresh bool, v interface{}) error {
+	cached, err := ioutil.ReadFile(c.cacheFile(path))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	body := cached
+	if !c.Offline && (refresh || cached == nil) {
+		downloaded, err := c.download(cdnPath)
+		if err == nil {
+			body = downloaded
+			if err = c.writeCache(path, body); err != nil {
+				return err
+			}
+		} else if cached == nil {
+			return err
+		}
+	}
+	if body == nil {
+		return fmt.Errorf("%w: %s", ErrNotCached, path)
+	}
+	return json.Unmarshal(body, v)
+}
+
+// download gets a file from the CDN
+func (c *Client) download(cdnPath string) ([]byte, error) {
+	base := c.BaseURL
+	if base == nil {
+		base, _ = url.Parse(DefaultBaseURL)
+	}
+	u := base.ResolveReference(&url.URL{Path: "/" + cdnPath})
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Get(u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Data Dragon %s: %s", cdnPath, resp.Status)
+	}
+	// Don't cache anything that isn't JSON, such as an error page
+	if !json.Valid(body) {
+		return nil, fmt.Errorf("Data Dragon %s: invalid JSON", cdnPath)
+	}
+	return body, nil
+}
+
+func (c *Client) cacheFile(path string) string {
+	return filepath.Join(c.CacheDir, filepath.FromSlash(path))
+}
+
+// writeCache saves a file to the cache, writing it whole so readers never see part of it
+func (c *Client) writeCache(path string, data []byte) error {
+	file := c.cacheFile(path)
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	tmp := file + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
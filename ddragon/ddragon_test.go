@@ -0,0 +1,168 @@
+package ddragon
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fixtureClient returns an offline client reading the fixtures in testdata
+func fixtureClient() *Client {
+	return &Client{CacheDir: "testdata", Offline: true}
+}
+
+// cdnServer serves versions.json, counting the requests for it
+func cdnServer(t *testing.T, versions string, requests *int32) *url.URL {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/versions.json" {
+			http.NotFound(w, r)
+			return
+		}
+		atomic.AddInt32(requests, 1)
+		w.Write([]byte(versions))
+	}))
+	t.Cleanup(server.Close)
+	u, _ := url.Parse(server.URL)
+	return u
+}
+
+func TestVersionFor(t *testing.T) {
+	dd := fixtureClient()
+	cases := []struct{ gameVersion, want string }{
+		{"14.3.556.1234", "14.3.1"},
+		{"14.4.560.1", "14.4.1"},
+		// A patch Data Dragon doesn't list gets the newest version before it
+		{"14.9.1.1", "14.4.1"},
+		{"not a version", "14.4.1"},
+	}
+	for _, tc := range cases {
+		if got, err := dd.VersionFor(tc.gameVersion); err != nil || got != tc.want {
+			t.Errorf("VersionFor(%q) = %q, %v, want %q", tc.gameVersion, got, err, tc.want)
+		}
+	}
+}
+
+func TestChampionsFromFixture(t *testing.T) {
+	champions, err := fixtureClient().Champions("14.3.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := champions.Names()
+	if names[62] != "Wukong" || names[20] != "Nunu & Willump" || len(names) != 3 {
+		t.Errorf("Names() = %v", names)
+	}
+	if c, ok := champions.ByKey(1); !ok || c.ID != "Annie" {
+		t.Errorf("ByKey(1) = %+v, %t", c, ok)
+	}
+}
+
+func TestOfflineNotCached(t *testing.T) {
+	_, err := fixtureClient().Champions("13.1.1")
+	if !errors.Is(err, ErrNotCached) {
+		t.Errorf("err = %v, want ErrNotCached", err)
+	}
+}
+
+func TestSeedAndSetDefault(t *testing.T) {
+	saved := Default()
+	defer SetDefault(saved)
+
+	dd := &Client{CacheDir: t.TempDir(), Offline: true}
+	SetDefault(dd)
+	if Default() != dd {
+		t.Fatal("Default() isn't the client passed to SetDefault")
+	}
+	err := dd.Seed("14.3.1", "champion.json", []byte(`{"data": {"Ahri": {"id": "Ahri", "key": "103", "name": "Ahri"}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	champions, err := Default().Champions("14.3.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := champions.Names()[103]; name != "Ahri" {
+		t.Errorf("seeded champion 103 = %q, want Ahri", name)
+	}
+}
+
+func TestVersionsDownloadedOnce(t *testing.T) {
+	var requests int32
+	dd := &Client{BaseURL: cdnServer(t, `["14.4.1", "14.3.1"]`, &requests), CacheDir: t.TempDir()}
+	for i := 0; i < 5; i++ {
+		if latest, err := dd.LatestVersion(); err != nil || latest != "14.4.1" {
+			t.Fatalf("LatestVersion() = %q, %v", latest, err)
+		}
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("versions.json downloaded %d times, want once", n)
+	}
+
+	// Another client, as in the next run, uses the fresh cache without downloading
+	again := &Client{BaseURL: dd.BaseURL, CacheDir: dd.CacheDir}
+	if _, err := again.Versions(); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("versions.json downloaded %d times with a fresh cache, want once", n)
+	}
+}
+
+func TestVersionsRefreshedWhenStale(t *testing.T) {
+	var requests int32
+	dir := t.TempDir()
+	cached := filepath.Join(dir, "versions.json")
+	if err := ioutil.WriteFile(cached, []byte(`["14.3.1"]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(cached, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	dd := &Client{BaseURL: cdnServer(t, `["14.4.1", "14.3.1"]`, &requests), CacheDir: dir, VersionsTTL: time.Hour}
+	if latest, err := dd.LatestVersion(); err != nil || latest != "14.4.1" {
+		t.Errorf("LatestVersion() = %q, %v, want the downloaded 14.4.1", latest, err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("versions.json downloaded %d times, want once", n)
+	}
+
+	// Offline, the stale cache is used as it is
+	if err := os.Chtimes(cached, old, old); err != nil {
+		t.Fatal(err)
+	}
+	offline := &Client{BaseURL: dd.BaseURL, CacheDir: dir, VersionsTTL: time.Hour, Offline: true}
+	if _, err := offline.Versions(); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("versions.json downloaded offline")
+	}
+}
+
+func TestVersionsUnreachableTriedOnce(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+
+	dd := &Client{BaseURL: u, CacheDir: t.TempDir()}
+	for i := 0; i < 3; i++ {
+		if _, err := dd.Versions(); err == nil {
+			t.Fatal("Versions() succeeded with nothing cached and Data Dragon down")
+		}
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("Data Dragon tried %d times, want once", n)
+	}
+}
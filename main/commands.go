@@ -0,0 +1,956 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/WhiteAcres/leaguestats/client"
+	"github.com/WhiteAcres/leaguestats/config"
+	"github.com/WhiteAcres/leaguestats/ddragon"
+	"github.com/WhiteAcres/leaguestats/render"
+	"github.com/WhiteAcres/leaguestats/stats"
+	"github.com/WhiteAcres/leaguestats/storage"
+)
+
+// summonerFlags - flags shared by the commands that look at a summoner
+type summonerFlags struct {
+	summoner string
+	platform string
+	format   string
+}
+
+// filterFlags - flags narrowing down the games a report is made from
+type filterFlags struct {
+	queue       string
+	patch       string
+	minPatch    string
+	maxPatch    string
+	since       string
+	until       string
+	champion    string
+	role        string
+	minDuration time.Duration
+}
+
+// newFlagSet creates the flag set of a command; errors are returned rather than exiting
+func newFlagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet("leaguestats "+name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: leaguestats %s %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func addSummonerFlags(fs *flag.FlagSet) *summonerFlags {
+	sf := &summonerFlags{}
+	fs.StringVar(&sf.summoner, "summoner", "", "Riot ID of the summoner (Name#TAG)")
+	fs.StringVar(&sf.format, "format", string(render.Table), "output format: "+render.FormatNames())
+	return sf
+}
+
+// addPlatformFlag adds --platform, for the commands that look the summoner up with the API
+func (sf *summonerFlags) addPlatformFlag(fs *flag.FlagSet) {
+	fs.StringVar(&sf.platform, "platform", "", "platform the summoner plays on (default from config)")
+}
+
+func addFilterFlags(fs *flag.FlagSet) *filterFlags {
+	ff := &filterFlags{}
+	fs.StringVar(&ff.queue, "queue", "", "only use games from these queues, by ID or name (solo, flex, ranked, normal, aram, ...), comma-separated")
+	fs.StringVar(&ff.patch, "patch", "", "only use games from this patch (e.g. 14.3)")
+	fs.StringVar(&ff.minPatch, "min-patch", "", "only use games from this patch or later")
+	fs.StringVar(&ff.maxPatch, "max-patch", "", "only use games from this patch or earlier")
+	fs.StringVar(&ff.since, "since", "", "only use games played on or after this date (YYYY-MM-DD)")
+	fs.StringVar(&ff.until, "until", "", "only use games played before this date (YYYY-MM-DD)")
+	fs.StringVar(&ff.champion, "champion", "", "only use games the summoner played this champion in, by name or ID")
+	fs.StringVar(&ff.role, "role", "", "only use games the summoner played this role in (top, jungle, mid, bot, support)")
+	fs.DurationVar(&ff.minDuration, "min-duration", stats.RemakeDuration, "leave out games shorter than this, such as remakes")
+	return ff
+}
+
+// matchFilter builds the stats filter from the flags, looking champion names up in store
+func (ff *filterFlags) matchFilter(store storage.Store) (stats.MatchFilter, error) {
+	filter := stats.MatchFilter{MinPatch: ff.minPatch, MaxPatch: ff.maxPatch, MinDuration: ff.minDuration}
+	var err error
+	if filter.QueueIDs, err = stats.ParseQueues(ff.queue); err != nil {
+		return filter, &usageError{err.Error()}
+	}
+	if len(ff.patch) > 0 {
+		if len(ff.minPatch) > 0 || len(ff.maxPatch) > 0 {
+			return filter, usagef("--patch can't be combined with --min-patch or --max-patch")
+		}
+		filter.MinPatch, filter.MaxPatch = ff.patch, ff.patch
+	}
+	if filter.Since, err = parseDate("--since", ff.since); err != nil {
+		return filter, err
+	}
+	if filter.Until, err = parseDate("--until", ff.until); err != nil {
+		return filter, err
+	}
+	if len(ff.role) > 0 {
+		if filter.Role, err = stats.ParseRole(ff.role); err != nil {
+			return filter, &usageError{err.Error()}
+		}
+	}
+	if err = filter.Validate(); err != nil {
+		return filter, &usageError{err.Error()}
+	}
+	if len(ff.champion) > 0 {
+		if filter.ChampionID, err = stats.FindChampionID(store, ff.champion); err != nil {
+			return filter, &usageError{err.Error()}
+		}
+	}
+	return filter, nil
+}
+
+// parseFlags parses a command's flags, turning bad flags into usage errors
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	err := fs.Parse(args)
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		return &usageError{err.Error()}
+	}
+	return err
+}
+
+// flagWasSet checks if a flag was given on the command line
+func flagWasSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// parseDate parses a YYYY-MM-DD date flag in local time; empty means no date
+func parseDate(flagName, value string) (time.Time, error) {
+	if len(value) == 0 {
+		return time.Time{}, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, usagef("%s must be a date like 2026-01-02", flagName)
+	}
+	return t, nil
+}
+
+// requireSummoner checks that --summoner was given
+func (sf *summonerFlags) requireSummoner() error {
+	if len(sf.summoner) == 0 {
+		return usagef("--summoner is required")
+	}
+	return nil
+}
+
+// playerID resolves --summoner, a Riot ID or an older summoner name, to the player
+// it belongs to in the store, so history under earlier names is included
+func (sf *summonerFlags) playerID(store storage.Store) (string, error) {
+	return stats.ResolveSummoner(store, strings.TrimSpace(sf.summoner))
+}
+
+// writeResults renders results to stdout in the --format format
+func (sf *summonerFlags) writeResults(results render.Tabular) error {
+	format, err := render.ParseFormat(sf.format)
+	if err != nil {
+		return &usageError{err.Error()}
+	}
+	return render.Write(os.Stdout, format, results)
+}
+
+// writeSections renders a report made of several tables to stdout in the --format
+// format as one document, or only the table named by section if it is set
+func (sf *summonerFlags) writeSections(section string, sections ...render.Section) error {
+	format, err := render.ParseFormat(sf.format)
+	if err != nil {
+		return &usageError{err.Error()}
+	}
+	var names []string
+	for _, s := range sections {
+		if strings.EqualFold(s.Name, section) {
+			return render.Write(os.Stdout, format, s.Results)
+		}
+		names = append(names, strings.ToLower(s.Name))
+	}
+	if len(section) > 0 {
+		return usagef("--section must be one of %s", strings.Join(names, ", "))
+	}
+	if format == render.CSV {
+		return usagef("--format csv holds one table; choose it with --section %s", strings.Join(names, "|"))
+	}
+	return render.WriteSections(os.Stdout, format, sections)
+}
+
+// newClient builds an API client from the config, using platform if it is set
+func newClient(conf *config.Conf, platform string) (*client.Client, error) {
+	if !conf.HasValidKey() {
+		return nil, errors.New("no valid API key configured; run 'leaguestats config set-key RGAPI-...'")
+	}
+	if len(platform) == 0 {
+		platform = conf.Platform
+	}
+	if len(platform) == 0 {
+		platform = config.DefaultPlatform
+	}
+	p, err := client.ParsePlatform(platform)
+	if err != nil {
+		return nil, &usageError{err.Error()}
+	}
+	timeout := requestTimeout
+	if timeout == 0 {
+		// Client treats zero as the default, so ask for no timeout explicitly
+		timeout = -1
+	}
+	return &client.Client{
+		Platform:   p,
+		APIKey:     conf.APIKey,
+		HTTPClient: &http.Client{},
+		Timeout:    timeout,
+		Retry:      client.RetryPolicy{MaxAttempts: conf.MaxAttempts},
+		Debug:      debugLog}, nil
+}
+
+// lookupAccount looks up a Riot ID and records the platform the player was found on.
+// Unless the platform was chosen for this lookup, a player looked up before goes back
+// to the platform recorded then, so they needn't be given it again. The player's
+// account ID is recorded as an alias of their PUUID, so their Match-V4 games count.
+func lookupAccount(ctx context.Context, cli *client.Client, store storage.Store, gameName, tagLine string, platformSet bool) (*client.Account, error) {
+	account, err := cli.GetAccountByRiotIDContext(ctx, gameName, tagLine)
+	if errors.Is(err, client.ErrNotFound) {
+		return nil, fmt.Errorf("Riot ID %s#%s not found on %s", gameName, tagLine, cli.Platform.AccountRegion())
+	} else if err != nil {
+		return nil, err
+	}
+	if !platformSet {
+		recorded, err := store.PlayerPlatform(account.PuuID)
+		if err != nil {
+			return nil, err
+		}
+		if p, err := client.ParsePlatform(recorded); err == nil {
+			cli.Platform = p
+			account.Platform = p
+		}
+	}
+	if err = store.SetPlayerPlatform(account.PuuID, string(account.Platform)); err != nil {
+		return nil, err
+	}
+	// Match-V4 games, imported from storage.json, know the player by account ID only
+	summoner, err := cli.GetSummonerByPUUIDContext(ctx, account.PuuID)
+	if errors.Is(err, context.Canceled) {
+		return nil, err
+	} else if err != nil {
+		fmt.Fprintln(os.Stderr, "Match-V4 games not linked:", client.Redact(err.Error()))
+	} else if len(summoner.AccountID) > 0 {
+		if err = store.AddPlayerAlias(client.AccountPlayerID(summoner.AccountID), account.PuuID); err != nil {
+			return nil, err
+		}
+	}
+	return account, nil
+}
+
+// collectNewMatchIDs walks the player's match history, most recent first, collecting
+// the match IDs not in storage until it reaches a stored match, the end of the history
+// or limit IDs (no limit if 0). With q.StartTime set the walk goes on past stored
+// matches, which the start time bounds, so older games and gaps left by an earlier
+// limited fetch are filled in.
+func collectNewMatchIDs(ctx context.Context, cli *client.Client, store storage.Store, puuid string, q client.MatchIDQuery, limit int) ([]string, error) {
+	var newMatchIDs []string
+	err := cli.WalkMatchIDsContext(ctx, puuid, q, func(matchIDs []string) (bool, error) {
+		filtered, err := store.FilterMatchIDs(matchIDs)
+		if err != nil {
+			return false, err
+		}
+		newMatchIDs = append(newMatchIDs, filtered...)
+		if limit > 0 && len(newMatchIDs) >= limit {
+			newMatchIDs = newMatchIDs[0:limit]
+			return false, nil
+		}
+		return len(filtered) == len(matchIDs) || !q.StartTime.IsZero(), nil
+	})
+	return newMatchIDs, err
+}
+
+// fetchNewMatches downloads up to limit (no limit if 0) of the player's matches not
+// already in storage, saving each as it arrives. Matches that fail are skipped and
+// returned as client.FetchErrors once the rest are saved. If ctx is cancelled the
+// matches saved so far are kept and ctx's error is returned.
+func fetchNewMatches(ctx context.Context, cli *client.Client, store storage.Store, puuid string, q client.MatchIDQuery, limit, workers int) (int, error) {
+	matchIDs, err := collectNewMatchIDs(ctx, cli, store, puuid, q, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	opts := client.FetchOptions{
+		Workers: workers,
+		Progress: func(done, total int) {
+			fmt.Fprintf(os.Stderr, "\rFetched %d/%d matches", done, total)
+			if done == total {
+				fmt.Fprintln(os.Stderr)
+			}
+		},
+	}
+	fetched := 0
+	fetchErrors := make(client.FetchErrors)
+	for result := range cli.FetchMatches(ctx, matchIDs, opts) {
+		if result.Err != nil {
+			if ctx.Err() != nil {
+				// Cancelled, not a failure of this match
+				continue
+			}
+			fetchErrors[result.MatchID] = result.Err
+			continue
+		}
+		if err = store.UpsertMatches([]*client.Match{result.Match}); err != nil {
+			return fetched, err
+		}
+		fetched++
+	}
+	if err = ctx.Err(); err != nil {
+		fmt.Fprintln(os.Stderr)
+		return fetched, err
+	}
+	if len(fetchErrors) > 0 {
+		return fetched, fetchErrors
+	}
+	return fetched, nil
+}
+
+// fetchTimelines downloads the timelines of the player's stored Match-V5 matches that
+// don't have one yet, up to limit (no limit if 0), most recent first. Failures and
+// cancellation are handled as in fetchNewMatches.
+func fetchTimelines(ctx context.Context, cli *client.Client, store storage.Store, puuid string, limit, workers int) (int, error) {
+	matches, err := store.Matches(storage.MatchQuery{PlayerID: puuid})
+	if err != nil {
+		return 0, err
+	}
+	var matchIDs []string
+	for _, match := range matches {
+		// The retired Match-V4 API can no longer serve timelines
+		if match.Metadata.DataVersion != "v4" {
+			matchIDs = append(matchIDs, match.Metadata.MatchID)
+		}
+	}
+	if matchIDs, err = store.FilterTimelineIDs(matchIDs); err != nil {
+		return 0, err
+	}
+	if limit > 0 && len(matchIDs) > limit {
+		matchIDs = matchIDs[0:limit]
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	opts := client.FetchOptions{
+		Workers: workers,
+		Progress: func(done, total int) {
+			fmt.Fprintf(os.Stderr, "\rFetched %d/%d timelines", done, total)
+			if done == total {
+				fmt.Fprintln(os.Stderr)
+			}
+		},
+	}
+	fetched := 0
+	fetchErrors := make(client.FetchErrors)
+	for result := range cli.FetchTimelines(ctx, matchIDs, opts) {
+		if result.Err != nil {
+			if ctx.Err() != nil {
+				continue
+			}
+			fetchErrors[result.MatchID] = result.Err
+			continue
+		}
+		if err = store.UpsertTimelines([]*client.Timeline{result.Timeline}); err != nil {
+			return fetched, err
+		}
+		fetched++
+	}
+	if err = ctx.Err(); err != nil {
+		fmt.Fprintln(os.Stderr)
+		return fetched, err
+	}
+	if len(fetchErrors) > 0 {
+		return fetched, fetchErrors
+	}
+	return fetched, nil
+}
+
+// saveLeagueSnapshot records the player's current ranked standing in each queue,
+// returning how many standings changed since the last fetch
+func saveLeagueSnapshot(ctx context.Context, cli *client.Client, store storage.Store, puuid string) (int, error) {
+	entries, err := cli.GetLeagueEntriesContext(ctx, puuid)
+	if err != nil {
+		return 0, err
+	}
+	return store.AddLeagueSnapshots(stats.SnapshotsFromEntries(puuid, entries, time.Now()))
+}
+
+// saveMasterySnapshot records the player's current mastery of each champion,
+// returning how many champions gained points since the last fetch
+func saveMasterySnapshot(ctx context.Context, cli *client.Client, store storage.Store, puuid string) (int, error) {
+	masteries, err := cli.GetChampionMasteriesContext(ctx, puuid)
+	if err != nil {
+		return 0, err
+	}
+	return store.AddMasterySnapshots(stats.MasterySnapshotsFromMasteries(puuid, masteries, time.Now()))
+}
+
+func runFetch(ctx context.Context, args []string) error {
+	fs := newFlagSet("fetch", "--summoner Name#TAG [flags]")
+	sf := addSummonerFlags(fs)
+	sf.addPlatformFlag(fs)
+	count := fs.Int("count", 50, "maximum number of new matches to download, 0 for no limit (default no limit with --since)")
+	since := fs.String("since", "", "walk back through history to this date (YYYY-MM-DD)")
+	until := fs.String("until", "", "only download games played before this date (YYYY-MM-DD)")
+	workers := fs.Int("workers", client.DefaultFetchWorkers, "number of matches to download at once")
+	queue := fs.String("queue", "", "only download games from this queue, by ID or name (e.g. solo)")
+	timelines := fs.Bool("timelines", false, "also download the timelines of stored matches, for the laning report (up to --count)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := sf.requireSummoner(); err != nil {
+		return err
+	}
+	q := client.MatchIDQuery{}
+	queueIDs, err := stats.ParseQueues(*queue)
+	if err != nil {
+		return &usageError{err.Error()}
+	} else if len(queueIDs) > 1 {
+		return usagef("fetch takes a single queue, got %q", *queue)
+	} else if len(queueIDs) == 1 {
+		q.Queue = queueIDs[0]
+	}
+	if q.StartTime, err = parseDate("--since", *since); err != nil {
+		return err
+	}
+	if q.EndTime, err = parseDate("--until", *until); err != nil {
+		return err
+	}
+	if !q.StartTime.IsZero() && !flagWasSet(fs, "count") {
+		*count = 0
+	}
+	gameName, tagLine, err := parseRiotID(sf.summoner)
+	if err != nil {
+		return &usageError{err.Error()}
+	}
+
+	cli, err := newClient(config.LoadConfig(), sf.platform)
+	if err != nil {
+		return err
+	}
+	store, err := storage.Open()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	account, err := lookupAccount(ctx, cli, store, gameName, tagLine, len(sf.platform) > 0)
+	if err != nil {
+		return err
+	}
+	fetched, fetchErr := fetchNewMatches(ctx, cli, store, account.PuuID, q, *count, *workers)
+	fmt.Printf("Fetched %d new matches for %s#%s\n", fetched, account.GameName, account.TagLine)
+	var fetchErrors client.FetchErrors
+	if fetchErr != nil && !errors.As(fetchErr, &fetchErrors) {
+		return fetchErr
+	}
+	// Record the standing and mastery the new games led to, for the ranked and mastery
+	// reports, even if some games failed. The matches are saved either way, so failing
+	// to record them is only reported.
+	if _, err = saveLeagueSnapshot(ctx, cli, store, account.PuuID); err != nil {
+		fmt.Fprintln(os.Stderr, "Ranked standing not recorded:", client.Redact(err.Error()))
+	}
+	if _, err = saveMasterySnapshot(ctx, cli, store, account.PuuID); err != nil {
+		fmt.Fprintln(os.Stderr, "Champion mastery not recorded:", client.Redact(err.Error()))
+	}
+	if *timelines {
+		fetched, err = fetchTimelines(ctx, cli, store, account.PuuID, *count, *workers)
+		fmt.Printf("Fetched %d new timelines for %s#%s\n", fetched, account.GameName, account.TagLine)
+		if err != nil && fetchErr == nil {
+			fetchErr = err
+		}
+	}
+	return fetchErr
+}
+
+func runBans(ctx context.Context, args []string) error {
+	fs := newFlagSet("bans", "--summoner Name#TAG [flags]")
+	sf := addSummonerFlags(fs)
+	ff := addFilterFlags(fs)
+	opts := stats.DefaultBanOptions
+	fs.Int64Var(&opts.MinGames, "min-games", opts.MinGames, "leave out champions faced in fewer games")
+	fs.DurationVar(&opts.HalfLife, "half-life", opts.HalfLife, "age at which a game counts half as much, 0 to weigh all games equally")
+	fs.Float64Var(&opts.Z, "z", opts.Z, "standard score of the win rate interval (1.96 for 95%)")
+	fs.Float64Var(&opts.LaneWeight, "lane-weight", opts.LaneWeight, "from 0 (all enemies count the same) to 1 (only lane opponents count)")
+	byLane := fs.Bool("by-lane", false, "recommend bans for each position played, against lane opponents only")
+	perLane := fs.Int("per-lane", 3, "with --by-lane, how many bans to list per position, 0 for all")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := sf.requireSummoner(); err != nil {
+		return err
+	}
+	if opts.MinGames < 0 || opts.HalfLife < 0 || opts.Z < 0 || *perLane < 0 {
+		return usagef("--min-games, --half-life, --z and --per-lane must not be negative")
+	}
+	if opts.LaneWeight < 0 || opts.LaneWeight > 1 {
+		return usagef("--lane-weight must be between 0 and 1")
+	}
+
+	store, err := storage.Open()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	filter, err := ff.matchFilter(store)
+	if err != nil {
+		return err
+	}
+	playerID, err := sf.playerID(store)
+	if err != nil {
+		return err
+	}
+	if *byLane {
+		laneBans, err := stats.GetLaneBansForSummoner(store, playerID, opts, *perLane, filter)
+		if err != nil {
+			return err
+		}
+		return sf.writeResults(laneBans)
+	}
+	banRecommendations, err := stats.GetBestBanForSummoner(store, playerID, opts, filter)
+	if err != nil {
+		return err
+	}
+	return sf.writeResults(banRecommendations)
+}
+
+func runSummary(ctx context.Context, args []string) error {
+	fs := newFlagSet("summary", "--summoner Name#TAG [flags]")
+	sf := addSummonerFlags(fs)
+	ff := addFilterFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := sf.requireSummoner(); err != nil {
+		return err
+	}
+
+	store, err := storage.Open()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	filter, err := ff.matchFilter(store)
+	if err != nil {
+		return err
+	}
+	playerID, err := sf.playerID(store)
+	if err != nil {
+		return err
+	}
+	summary, err := stats.GetSummaryForSummoner(store, playerID, filter)
+	if err != nil {
+		return err
+	}
+	return sf.writeResults(summary)
+}
+
+func runChampions(ctx context.Context, args []string) error {
+	fs := newFlagSet("champions", "--summoner Name#TAG [flags]")
+	sf := addSummonerFlags(fs)
+	ff := addFilterFlags(fs)
+	var opts stats.ChampionStatsOptions
+	sortBy := fs.String("sort", string(stats.SortByGames), "order champions by games, winrate, kda, cs, gold, damage, vision, duration or name")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := sf.requireSummoner(); err != nil {
+		return err
+	}
+	var err error
+	if opts.SortBy, err = stats.ParseChampionSort(*sortBy); err != nil {
+		return &usageError{err.Error()}
+	}
+
+	store, err := storage.Open()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	filter, err := ff.matchFilter(store)
+	if err != nil {
+		return err
+	}
+	playerID, err := sf.playerID(store)
+	if err != nil {
+		return err
+	}
+	championStats, err := stats.GetChampionStatsForSummoner(store, playerID, opts, filter)
+	if err != nil {
+		return err
+	}
+	return sf.writeResults(championStats)
+}
+
+func runMatches(ctx context.Context, args []string) error {
+	fs := newFlagSet("matches", "--summoner Name#TAG [flags]")
+	sf := addSummonerFlags(fs)
+	ff := addFilterFlags(fs)
+	count := fs.Int("count", 20, "number of matches to list (0 for all)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := sf.requireSummoner(); err != nil {
+		return err
+	}
+
+	store, err := storage.Open()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	filter, err := ff.matchFilter(store)
+	if err != nil {
+		return err
+	}
+	playerID, err := sf.playerID(store)
+	if err != nil {
+		return err
+	}
+	matchSummaries, err := stats.GetMatchSummariesForSummoner(store, playerID, *count, filter)
+	if err != nil {
+		return err
+	}
+	return sf.writeResults(matchSummaries)
+}
+
+func runLaning(ctx context.Context, args []string) error {
+	fs := newFlagSet("laning", "--summoner Name#TAG [flags]")
+	sf := addSummonerFlags(fs)
+	ff := addFilterFlags(fs)
+	deaths := fs.Bool("deaths", false, "list where each death happened instead of the laning phases")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := sf.requireSummoner(); err != nil {
+		return err
+	}
+
+	store, err := storage.Open()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	filter, err := ff.matchFilter(store)
+	if err != nil {
+		return err
+	}
+	playerID, err := sf.playerID(store)
+	if err != nil {
+		return err
+	}
+	if *deaths {
+		deathList, err := stats.GetDeathsForSummoner(store, playerID, filter)
+		if err != nil {
+			return err
+		}
+		return sf.writeResults(deathList)
+	}
+	laningStats, err := stats.GetLaningStatsForSummoner(store, playerID, filter)
+	if err != nil {
+		return err
+	}
+	return sf.writeResults(laningStats)
+}
+
+func runRanked(ctx context.Context, args []string) error {
+	fs := newFlagSet("ranked", "--summoner Name#TAG [flags]")
+	sf := addSummonerFlags(fs)
+	queue := fs.String("queue", "solo", "ranked queue to show, solo or flex")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := sf.requireSummoner(); err != nil {
+		return err
+	}
+	queueIDs, err := stats.ParseQueues(*queue)
+	if err != nil {
+		return &usageError{err.Error()}
+	} else if len(queueIDs) != 1 {
+		return usagef("ranked takes a single queue, solo or flex, got %q", *queue)
+	}
+	queueType, err := stats.RankedQueueType(queueIDs[0])
+	if err != nil {
+		return &usageError{err.Error()}
+	}
+
+	store, err := storage.Open()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	playerID, err := sf.playerID(store)
+	if err != nil {
+		return err
+	}
+	history, err := stats.GetRankedHistoryForSummoner(store, playerID, queueType)
+	if err != nil {
+		return err
+	}
+	return sf.writeResults(history)
+}
+
+func runMastery(ctx context.Context, args []string) error {
+	fs := newFlagSet("mastery", "--summoner Name#TAG [flags]")
+	sf := addSummonerFlags(fs)
+	ff := addFilterFlags(fs)
+	minGames := fs.Int64("min-games", 3, "leave out champions played fewer times than this")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := sf.requireSummoner(); err != nil {
+		return err
+	}
+	if *minGames < 0 {
+		return usagef("--min-games must not be negative")
+	}
+
+	store, err := storage.Open()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	filter, err := ff.matchFilter(store)
+	if err != nil {
+		return err
+	}
+	playerID, err := sf.playerID(store)
+	if err != nil {
+		return err
+	}
+	masteryStats, err := stats.GetMasteryStatsForSummoner(store, playerID, *minGames, filter)
+	if err != nil {
+		return err
+	}
+	return sf.writeResults(masteryStats)
+}
+
+func runLive(ctx context.Context, args []string) error {
+	fs := newFlagSet("live", "--summoner Name#TAG [flags]")
+	sf := addSummonerFlags(fs)
+	sf.addPlatformFlag(fs)
+	enemyGames := fs.Int("enemy-games", 10, "new matches to download for each player in the game first, 0 to use stored matches only")
+	workers := fs.Int("workers", client.DefaultFetchWorkers, "number of matches to download at once")
+	poolSize := fs.Int("pool", 3, "how many of each enemy's most played champions to list")
+	banCount := fs.Int("bans", 3, "how many ban suggestions to list, 0 for all")
+	section := fs.String("section", "", "print only the enemies or the bans")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := sf.requireSummoner(); err != nil {
+		return err
+	}
+	if *enemyGames < 0 || *poolSize < 0 || *banCount < 0 {
+		return usagef("--enemy-games, --pool and --bans must not be negative")
+	}
+	gameName, tagLine, err := parseRiotID(sf.summoner)
+	if err != nil {
+		return &usageError{err.Error()}
+	}
+
+	cli, err := newClient(config.LoadConfig(), sf.platform)
+	if err != nil {
+		return err
+	}
+	store, err := storage.Open()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	account, err := lookupAccount(ctx, cli, store, gameName, tagLine, len(sf.platform) > 0)
+	if err != nil {
+		return err
+	}
+	game, err := cli.GetActiveGameContext(ctx, account.PuuID)
+	if errors.Is(err, client.ErrNotFound) {
+		return fmt.Errorf("%s#%s is not in a game on %s", account.GameName, account.TagLine, cli.Platform)
+	} else if err != nil {
+		return err
+	}
+
+	if *enemyGames > 0 {
+		// Bring the summoner's and the enemies' histories up to date
+		summoner, _ := game.Participant(account.PuuID)
+		for _, p := range game.Participants {
+			if p.Bot || len(p.PuuID) == 0 || (p.TeamID == summoner.TeamID && p.PuuID != account.PuuID) {
+				continue
+			}
+			_, err = fetchNewMatches(ctx, cli, store, p.PuuID, client.MatchIDQuery{}, *enemyGames, *workers)
+			if errors.Is(err, context.Canceled) {
+				return err
+			} else if err != nil {
+				// Go on with whatever was saved
+				fmt.Fprintln(os.Stderr, client.Redact(err.Error()))
+			}
+		}
+	}
+
+	enemies, err := stats.GetLiveEnemies(store, game, account.PuuID, *poolSize)
+	if err != nil {
+		return err
+	}
+	recommendations, err := stats.GetBestBanForSummoner(store, account.PuuID, stats.DefaultBanOptions, stats.MatchFilter{MinDuration: stats.RemakeDuration})
+	if err != nil {
+		return err
+	}
+	return sf.writeSections(*section,
+		render.Section{Name: "Enemies", Results: enemies},
+		render.Section{Name: "Bans", Results: stats.LiveBans(recommendations, game, *banCount)})
+}
+
+func runScout(ctx context.Context, args []string) error {
+	fs := newFlagSet("scout", "Name#TAG [flags]")
+	sf := addSummonerFlags(fs)
+	sf.addPlatformFlag(fs)
+	ff := addFilterFlags(fs)
+	count := fs.Int("count", 20, "maximum number of new matches to download first, 0 for no limit")
+	workers := fs.Int("workers", client.DefaultFetchWorkers, "number of matches to download at once")
+	offline := fs.Bool("offline", false, "use stored matches and cached Data Dragon data only, without contacting the API")
+	section := fs.String("section", "", "print only the summary or the champions")
+	// The Riot ID may be given before the flags
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		sf.summoner, args = args[0], args[1:]
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 1 && len(sf.summoner) == 0 {
+		sf.summoner = fs.Arg(0)
+	} else if fs.NArg() > 0 {
+		return usagef("scout takes one Riot ID, got %q", fs.Args())
+	}
+	if err := sf.requireSummoner(); err != nil {
+		return err
+	}
+	if *count < 0 {
+		return usagef("--count must not be negative")
+	}
+
+	store, err := storage.Open()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	filter, err := ff.matchFilter(store)
+	if err != nil {
+		return err
+	}
+
+	var playerID string
+	if *offline {
+		ddragon.Default().Offline = true
+		if playerID, err = sf.playerID(store); err != nil {
+			return err
+		}
+	} else {
+		gameName, tagLine, err := parseRiotID(sf.summoner)
+		if err != nil {
+			return &usageError{err.Error()}
+		}
+		cli, err := newClient(config.LoadConfig(), sf.platform)
+		if err != nil {
+			return err
+		}
+		account, err := lookupAccount(ctx, cli, store, gameName, tagLine, len(sf.platform) > 0)
+		if err != nil {
+			return err
+		}
+		playerID = account.PuuID
+		// Only games newer than the stored ones are downloaded, so scouting again is quick
+		_, err = fetchNewMatches(ctx, cli, store, playerID, client.MatchIDQuery{}, *count, *workers)
+		if errors.Is(err, context.Canceled) {
+			return err
+		} else if err != nil {
+			// Go on with whatever was saved
+			fmt.Fprintln(os.Stderr, client.Redact(err.Error()))
+		}
+		if _, err = saveMasterySnapshot(ctx, cli, store, playerID); err != nil {
+			fmt.Fprintln(os.Stderr, "Champion mastery not recorded:", client.Redact(err.Error()))
+		}
+	}
+
+	summary, champions, err := stats.ScoutSummoner(store, playerID, filter)
+	if err != nil {
+		return err
+	}
+	return sf.writeSections(*section,
+		render.Section{Name: "Summary", Results: summary},
+		render.Section{Name: "Champions", Results: champions})
+}
+
+func runConfig(ctx context.Context, args []string) error {
+	if len(args) != 2 {
+		return usagef("usage: leaguestats config set-key RGAPI-... | set-platform PLATFORM | set-attempts N")
+	}
+	conf := config.LoadConfig()
+	switch args[0] {
+	case "set-key":
+		if err := conf.SetAPIKey(args[1]); err != nil {
+			return &usageError{err.Error()}
+		}
+		fmt.Println("API Key saved")
+	case "set-platform":
+		p, err := client.ParsePlatform(args[1])
+		if err != nil {
+			return &usageError{err.Error()}
+		}
+		conf.Platform = string(p)
+		conf.SaveConfig()
+		fmt.Printf("Default platform set to %s\n", p)
+	case "set-attempts":
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 1 {
+			return usagef("attempts must be a whole number of at least 1, got %q", args[1])
+		}
+		conf.MaxAttempts = n
+		conf.SaveConfig()
+		fmt.Printf("Requests will be sent at most %d times on server errors\n", n)
+	default:
+		return usagef("unknown config action %q", args[0])
+	}
+	return nil
+}
+
+func runStorage(ctx context.Context, args []string) error {
+	if len(args) == 0 || args[0] != "prune" {
+		return usagef("usage: leaguestats storage prune --keep N")
+	}
+	fs := newFlagSet("storage prune", "--keep N")
+	keep := fs.Int("keep", -1, "number of most recent matches to keep")
+	if err := parseFlags(fs, args[1:]); err != nil {
+		return err
+	}
+	if *keep < 0 {
+		return usagef("--keep is required")
+	}
+
+	store, err := storage.Open()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	deleted, err := store.Prune(*keep)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Pruned %d matches\n", deleted)
+	return nil
+}
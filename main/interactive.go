@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/WhiteAcres/leaguestats/client"
+	"github.com/WhiteAcres/leaguestats/config"
+	"github.com/WhiteAcres/leaguestats/render"
+	"github.com/WhiteAcres/leaguestats/stats"
+	"github.com/WhiteAcres/leaguestats/storage"
+)
+
+// readLines sends each line read from r until it fails, then the error
+func readLines(r io.Reader, lines chan<- string, errs chan<- error) {
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			errs <- err
+			return
+		}
+		lines <- line
+	}
+}
+
+func runInteractive(ctx context.Context, args []string) error {
+	fs := newFlagSet("interactive", "")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	conf := config.LoadConfig()
+	conf.ValidateConfig()
+	cli, err := newClient(conf, "")
+	if err != nil {
+		return err
+	}
+	platform := cli.Platform
+
+	store, err := storage.Open()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	// Stdin is read in the background so Ctrl-C can stop a pending prompt
+	lines := make(chan string)
+	errs := make(chan error, 1)
+	go readLines(os.Stdin, lines, errs)
+
+	// An expired key is replaced from the prompt rather than failing the lookup
+	cli.OnUnauthorized = func() (string, error) {
+		fmt.Println("API Key was unauthorized (probably expired)")
+		fmt.Println("Generate new API Key at https://developer.riotgames.com")
+		fmt.Print("Enter the New API Key:\n")
+		select {
+		case line := <-lines:
+			if err := conf.SetAPIKey(strings.TrimSpace(line)); err != nil {
+				return "", err
+			}
+			return conf.APIKey, nil
+		case err := <-errs:
+			return "", err
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+
+	// main loop
+	for {
+		// Get Riot ID
+		fmt.Printf("Enter your Riot ID (Name#TAG) on %s, optionally followed by another platform:\n", platform)
+		var riotID string
+		select {
+		case riotID = <-lines:
+		case err := <-errs:
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		case <-ctx.Done():
+			return nil
+		}
+		riotID = strings.Replace(riotID, "\n", "", -1)
+		riotID = strings.Replace(riotID, "\r", "", -1)
+
+		// A trailing platform (e.g. "Name#TAG EUW1") overrides the default for this lookup
+		cli.Platform = platform
+		platformSet := false
+		if i := strings.LastIndex(riotID, " "); i > strings.Index(riotID, "#") {
+			if p, err := client.ParsePlatform(riotID[i+1:]); err == nil {
+				cli.Platform = p
+				platformSet = true
+				riotID = riotID[:i]
+			}
+		}
+		gameName, tagLine, err := parseRiotID(riotID)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+
+		account, err := lookupAccount(ctx, cli, store, gameName, tagLine, platformSet)
+		if errors.Is(err, context.Canceled) {
+			return nil
+		} else if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		_, err = fetchNewMatches(ctx, cli, store, account.PuuID, client.MatchIDQuery{}, 50, client.DefaultFetchWorkers)
+		if errors.Is(err, context.Canceled) {
+			return nil
+		} else if err != nil {
+			// Recommend bans from whatever was saved
+			fmt.Println(err)
+		}
+		banRecommendations, err := stats.GetBestBanForSummoner(store, account.PuuID, stats.DefaultBanOptions, stats.MatchFilter{MinDuration: stats.RemakeDuration})
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		if err = render.Write(os.Stdout, render.Table, banRecommendations); err != nil {
+			return err
+		}
+		fmt.Println("")
+	}
+}
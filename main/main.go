@@ -1,85 +1,155 @@
 package main
 
 import (
-	"bufio"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
-	"net/http"
-	"net/url"
 	"os"
-	"strconv"
+	"os/signal"
 	"strings"
+	"syscall"
 
 	"github.com/WhiteAcres/leaguestats/client"
-	"github.com/WhiteAcres/leaguestats/config"
-	"github.com/WhiteAcres/leaguestats/stats"
-	"github.com/WhiteAcres/leaguestats/storage"
+	"github.com/WhiteAcres/leaguestats/ddragon"
+	"github.com/WhiteAcres/leaguestats/paths"
 )
 
-func main() {
+// Exit codes
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
 
-	conf := config.LoadConfig()
-	conf.ValidateConfig()
+// command - a leaguestats subcommand
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, args []string) error
+}
 
-	storage := storage.LoadStorage()
+// usageError - an error caused by bad arguments, reported with exit code 2
+type usageError struct {
+	msg string
+}
 
-	// initializing the client
-	url, _ := url.Parse("https://na1.api.riotgames.com")
-	cli := &client.Client{
-		BaseURL:    url,
-		APIKey:     conf.APIKey,
-		HTTPClient: &http.Client{}}
+func (e *usageError) Error() string {
+	return e.msg
+}
 
-	// main loop
-	for {
-		// Get summoner name
-		reader := bufio.NewReader(os.Stdin)
-		fmt.Print("Enter your Summoner Name:\n")
-		summonerName, err := reader.ReadString('\n')
-		if err != nil {
-			fmt.Println(err)
-			log.Fatal(err)
-		}
-		summonerName = strings.Replace(summonerName, "\n", "", -1)
-		summonerName = strings.Replace(summonerName, "\r", "", -1)
+func usagef(format string, a ...interface{}) error {
+	return &usageError{fmt.Sprintf(format, a...)}
+}
 
-		si, err := cli.GetSummonerInfo(summonerName)
-		if err != nil {
-			fmt.Println(err)
-			log.Fatal(err)
-		}
+var commands = []command{
+	{"fetch", "download new matches for a summoner into storage", runFetch},
+	{"bans", "recommend bans from a summoner's stored matches", runBans},
+	{"summary", "show a summoner's overall record", runSummary},
+	{"champions", "show a summoner's record and performance on each champion", runChampions},
+	{"matches", "list a summoner's stored matches", runMatches},
+	{"ranked", "show a summoner's tier and LP after each fetch, with the games in between", runRanked},
+	{"mastery", "compare a summoner's champion mastery with their win rate on each champion", runMastery},
+	{"laning", "show a summoner's laning phases or deaths from match timelines", runLaning},
+	{"scout", "size up an opponent: champion pool, main role, streaks and averages", runScout},
+	{"live", "show the enemies in a summoner's game in progress and ban suggestions", runLive},
+	{"config", "change settings (set-key, set-platform, set-attempts)", runConfig},
+	{"storage", "manage match storage (prune)", runStorage},
+	{"interactive", "prompt for Riot IDs and recommend bans, as before", runInteractive},
+}
 
-		// Get the 9matches list
-		ml, err := cli.GetMatchList(si.AccountID)
-		if err != nil {
-			fmt.Println(err)
-			log.Fatal(err)
-		}
+// requestTimeout bounds each API request, set by the global --timeout flag
+var requestTimeout = client.DefaultTimeout
 
-		// Pull out all gameIDs
-		var gameIDs []int64
-		for _, match := range ml.Matches {
-			gameIDs = append(gameIDs, match.GameID)
-		}
+// debugLog logs API traffic to stderr when the global --debug flag is set
+var debugLog *log.Logger
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: leaguestats [--data-dir DIR] [--timeout D] [--debug] [--ddragon-offline] <command> [flags]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %-12s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(out, "\nGlobal flags:\n")
+	flag.PrintDefaults()
+	fmt.Fprintf(out, "\nRun 'leaguestats <command> -h' for the flags of a command.\n")
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
 
-		// Filter out some gameIDs
-		gameIDs = storage.FilterGameIDs(gameIDs)
-		if len(gameIDs) > 50 {
-			gameIDs = gameIDs[0:50]
+// run executes the command line and returns the process exit code
+func run(args []string) int {
+	// A fresh flag set each time, so run can be called more than once (e.g. by tests)
+	flag.CommandLine = flag.NewFlagSet("leaguestats", flag.ContinueOnError)
+	flag.CommandLine.Usage = usage
+	dataDir := flag.String("data-dir", "", "directory for config and match storage (overrides $"+paths.EnvDataDir+")")
+	flag.DurationVar(&requestTimeout, "timeout", client.DefaultTimeout, "time limit for each API request, 0 for none")
+	debug := flag.Bool("debug", false, "log API requests and responses to stderr, with the API key redacted")
+	ddragonOffline := flag.Bool("ddragon-offline", false, "use cached Data Dragon data (champion names) only, never downloading it")
+	if err := flag.CommandLine.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
 		}
+		return exitUsage
+	}
+	if len(*dataDir) > 0 {
+		paths.SetDataDir(*dataDir)
+	}
+	if *debug {
+		debugLog = log.New(os.Stderr, "debug: ", log.LstdFlags)
+	}
+	if *ddragonOffline {
+		ddragon.Default().Offline = true
+	}
 
-		// Get the match information for the gameIDs
-		var matches []*client.Match
-		for _, gameID := range gameIDs {
-			m, err := cli.GetMatch(strconv.FormatInt(gameID, 10))
-			if err != nil {
-				fmt.Println(err)
-				log.Fatal(err)
-			}
-			matches = append(matches, m)
+	if flag.NArg() == 0 {
+		usage()
+		return exitUsage
+	}
+	name := flag.Arg(0)
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		// Ctrl-C cancels in-flight requests; commands return what they saved so
+		// far and their deferred cleanup closes storage before the process exits
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		err := cmd.run(ctx, flag.Args()[1:])
+		stop()
+		var uerr *usageError
+		switch {
+		case err == nil:
+			return exitOK
+		case errors.Is(err, flag.ErrHelp):
+			return exitOK
+		case errors.Is(err, context.Canceled):
+			fmt.Fprintf(os.Stderr, "leaguestats %s: interrupted\n", name)
+			return exitError
+		case errors.Is(err, client.ErrUnauthorized):
+			fmt.Fprintf(os.Stderr, "leaguestats %s: %s; run 'leaguestats config set-key RGAPI-...'\n", name, client.Redact(err.Error()))
+			return exitError
+		case errors.As(err, &uerr):
+			// A key pasted into the wrong argument ends up in the message
+			fmt.Fprintf(os.Stderr, "leaguestats %s: %s\n", name, client.Redact(err.Error()))
+			return exitUsage
+		default:
+			fmt.Fprintf(os.Stderr, "leaguestats %s: %s\n", name, client.Redact(err.Error()))
+			return exitError
 		}
-		storage.UpsertRecords(matches)
-		stats.GetBestBanForSummoner(*storage, summonerName)
-		fmt.Println("")
 	}
+	fmt.Fprintf(os.Stderr, "leaguestats: unknown command %q\n", name)
+	usage()
+	return exitUsage
+}
+
+// parseRiotID splits a Riot ID such as "Name#TAG" into its game name and tag line
+func parseRiotID(riotID string) (gameName, tagLine string, err error) {
+	gameName, tagLine, ok := strings.Cut(strings.TrimSpace(riotID), "#")
+	if !ok || len(gameName) == 0 || len(tagLine) == 0 {
+		return "", "", fmt.Errorf("Riot ID %q must look like Name#TAG", riotID)
+	}
+	return gameName, tagLine, nil
 }
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/WhiteAcres/leaguestats/client"
+	"github.com/WhiteAcres/leaguestats/render"
+	"github.com/WhiteAcres/leaguestats/stats"
+	"github.com/WhiteAcres/leaguestats/storage"
+)
+
+const testKey = "RGAPI-0123abcd-4567-89ab-cdef-0123456789ab"
+
+// runCaptured runs the command line, returning its exit code and what it wrote to stderr
+func runCaptured(t *testing.T, args ...string) (int, string) {
+	stderr, err := ioutil.TempFile(t.TempDir(), "stderr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stderr.Close()
+	saved := os.Stderr
+	os.Stderr = stderr
+	defer func() { os.Stderr = saved }()
+
+	code := run(append([]string{"--data-dir", t.TempDir()}, args...))
+	b, err := ioutil.ReadFile(stderr.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code, string(b)
+}
+
+func TestRunStderrRedacted(t *testing.T) {
+	cases := []struct {
+		args []string
+		code int
+	}{
+		// The key pasted where a Riot ID goes is a usage error...
+		{[]string{"fetch", "--summoner", testKey}, exitUsage},
+		{[]string{"config", "set-platform", testKey}, exitUsage},
+		// ...or, for the offline reports, an unknown summoner
+		{[]string{"bans", "--summoner", testKey}, exitError},
+		{[]string{"scout", testKey, "--offline"}, exitError},
+	}
+	for _, tc := range cases {
+		code, stderr := runCaptured(t, tc.args...)
+		if code != tc.code {
+			t.Errorf("%v exited with %d, want %d; stderr:\n%s", tc.args, code, tc.code, stderr)
+		}
+		if strings.Contains(stderr, testKey) {
+			t.Errorf("%v wrote the API key to stderr:\n%s", tc.args, stderr)
+		}
+		if !strings.Contains(stderr, "RGAPI-REDACTED") {
+			t.Errorf("%v stderr doesn't show where the key was redacted:\n%s", tc.args, stderr)
+		}
+	}
+}
+
+func TestWriteSectionsUsage(t *testing.T) {
+	sections := []render.Section{
+		{Name: "Enemies", Results: stats.LiveEnemies{}},
+		{Name: "Bans", Results: stats.BanRecommendations{}},
+	}
+	cases := []struct {
+		format, section string
+	}{
+		// CSV has room for one of the tables only
+		{"csv", ""},
+		{"json", "champions"},
+	}
+	for _, tc := range cases {
+		sf := &summonerFlags{format: tc.format}
+		var usage *usageError
+		if err := sf.writeSections(tc.section, sections...); !errors.As(err, &usage) {
+			t.Errorf("writeSections(%q) in %s = %v, want a usage error", tc.section, tc.format, err)
+		}
+	}
+}
+
+func TestPlatformFlag(t *testing.T) {
+	// Only the commands that call the API take --platform
+	for _, command := range []string{"fetch", "live", "scout"} {
+		_, stderr := runCaptured(t, command, "--platform", "EUW1")
+		if strings.Contains(stderr, "not defined: -platform") {
+			t.Errorf("%s doesn't take --platform:\n%s", command, stderr)
+		}
+	}
+	for _, command := range []string{"bans", "summary", "champions", "matches", "ranked", "mastery", "laning"} {
+		code, stderr := runCaptured(t, command, "--summoner", "Name#TAG", "--platform", "EUW1")
+		if code != exitUsage || !strings.Contains(stderr, "not defined: -platform") {
+			t.Errorf("%s --platform exited with %d, want a usage error:\n%s", command, code, stderr)
+		}
+	}
+}
+
+func TestCollectNewMatchIDs(t *testing.T) {
+	// A history of NA1_8 (most recent) down to NA1_1, served 3 IDs a page
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start, _ := strconv.Atoi(r.URL.Query().Get("start"))
+		count, _ := strconv.Atoi(r.URL.Query().Get("count"))
+		matchIDs := []string{}
+		for i := start; i < start+count && i < 8; i++ {
+			matchIDs = append(matchIDs, fmt.Sprintf("NA1_%d", 8-i))
+		}
+		json.NewEncoder(w).Encode(matchIDs)
+	}))
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+	cli := &client.Client{BaseURL: u, APIKey: "test"}
+
+	store, err := storage.OpenSQLite(filepath.Join(t.TempDir(), "matches.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	// NA1_7, on the first page, was saved by an earlier fetch
+	stored := &client.Match{Metadata: client.MatchMetadata{MatchID: "NA1_7"}, Info: client.MatchInfo{GameVersion: "14.3.1"}}
+	if err = store.UpsertMatches([]*client.Match{stored}); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name  string
+		q     client.MatchIDQuery
+		limit int
+		want  string
+	}{
+		// The plain incremental fetch stops at the page holding a stored match
+		{"incremental", client.MatchIDQuery{Count: 3}, 0, "NA1_8 NA1_6"},
+		// --since walks on to the start time, the end of this history
+		{"since", client.MatchIDQuery{Count: 3, StartTime: time.Now().AddDate(-1, 0, 0)}, 0, "NA1_8 NA1_6 NA1_5 NA1_4 NA1_3 NA1_2 NA1_1"},
+		{"since with a limit", client.MatchIDQuery{Count: 3, StartTime: time.Now().AddDate(-1, 0, 0)}, 4, "NA1_8 NA1_6 NA1_5 NA1_4"},
+	}
+	for _, tc := range cases {
+		matchIDs, err := collectNewMatchIDs(context.Background(), cli, store, "puuid", tc.q, tc.limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Join(matchIDs, " "); got != tc.want {
+			t.Errorf("%s: collected %s, want %s", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestLookupAccountLinksMatchV4(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/riot/account/v1/accounts/by-riot-id/Bravo/NA1":
+			w.Write([]byte(`{"puuid": "puuid-b", "gameName": "Bravo", "tagLine": "NA1"}`))
+		case "/lol/summoner/v4/summoners/by-puuid/puuid-b":
+			w.Write([]byte(`{"puuid": "puuid-b", "accountId": "acc-b"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+	cli := &client.Client{BaseURL: u, APIKey: "test", Platform: client.NA1}
+
+	store, err := storage.OpenSQLite(filepath.Join(t.TempDir(), "matches.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	// One game from before PUUIDs, under the account ID, and one after
+	legacy := &client.MatchV4{GameID: 100, PlatformID: "NA1", GameCreation: 1000, GameVersion: "10.25.1",
+		ParticipantIdentities: []client.ParticipantIdentity{{ParticipantID: 1,
+			Player: client.Player{AccountID: "acc-b", SummonerName: "Bravo"}}},
+		Participants: []client.ParticipantV4{{ParticipantID: 1, Stats: client.ParticipantStats{Win: true}}},
+	}
+	v4 := legacy.ToV5()
+	v5 := &client.Match{
+		Metadata: client.MatchMetadata{MatchID: "NA1_200"},
+		Info: client.MatchInfo{GameCreation: 2000, GameVersion: "14.3.1", Participants: []client.Participant{
+			{ParticipantID: 1, PuuID: "puuid-b", RiotIDGameName: "Bravo", RiotIDTagline: "NA1"},
+		}},
+	}
+	if err = store.UpsertMatches([]*client.Match{&v4, v5}); err != nil {
+		t.Fatal(err)
+	}
+
+	account, err := lookupAccount(context.Background(), cli, store, "Bravo", "NA1", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary, err := stats.GetSummaryForSummoner(store, account.PuuID, stats.MatchFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Games != 2 || summary.Wins != 1 {
+		t.Errorf("summary of %s = %d games and %d wins, want both games", account.PuuID, summary.Games, summary.Wins)
+	}
+	if playerID, err := stats.ResolveSummoner(store, "Bravo"); err != nil || playerID != "puuid-b" {
+		t.Errorf("ResolveSummoner(\"Bravo\") = %q, %v, want puuid-b", playerID, err)
+	}
+}
@@ -0,0 +1,96 @@
+package patch
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Patch - a League of Legends game version, such as 14.3 or the 14.3.556.1234 a
+// match reports. Major and minor make up the patch; build and revision are optional
+// and zero when absent.
+type Patch struct {
+	Major    int
+	Minor    int
+	Build    int
+	Revision int
+	// parts is how many sections were parsed, so String gives back the same form
+	parts int
+}
+
+// Parse parses a game version of two to four dot-separated numbers
+func Parse(version string) (Patch, error) {
+	sections := strings.Split(strings.TrimSpace(version), ".")
+	if len(sections) < 2 || len(sections) > 4 {
+		return Patch{}, fmt.Errorf("invalid game version %q: want major.minor with optional build parts", version)
+	}
+	var numbers [4]int
+	for i, section := range sections {
+		n, err := strconv.Atoi(section)
+		if err != nil || n < 0 {
+			return Patch{}, fmt.Errorf("invalid game version %q: %q is not a number", version, section)
+		}
+		numbers[i] = n
+	}
+	return Patch{
+		Major:    numbers[0],
+		Minor:    numbers[1],
+		Build:    numbers[2],
+		Revision: numbers[3],
+		parts:    len(sections),
+	}, nil
+}
+
+// String formats the patch with as many sections as it was parsed from, at least two
+func (p Patch) String() string {
+	numbers := []int{p.Major, p.Minor, p.Build, p.Revision}
+	parts := p.parts
+	if parts < 2 {
+		parts = 2
+	}
+	sections := make([]string, parts)
+	for i := range sections {
+		sections[i] = strconv.Itoa(numbers[i])
+	}
+	return strings.Join(sections, ".")
+}
+
+// Compare returns -1, 0 or 1 as p is older than, the same as or newer than q.
+// Missing build parts count as zero, so 14.3 and 14.3.0 are the same.
+func (p Patch) Compare(q Patch) int {
+	a := []int{p.Major, p.Minor, p.Build, p.Revision}
+	b := []int{q.Major, q.Minor, q.Build, q.Revision}
+	for i := range a {
+		if a[i] < b[i] {
+			return -1
+		} else if a[i] > b[i] {
+			return 1
+		}
+	}
+	return 0
+}
+
+// Less reports whether p is older than q
+func (p Patch) Less(q Patch) bool {
+	return p.Compare(q) < 0
+}
+
+// Bucket returns the major.minor patch the version belongs to, e.g. 14.3 for 14.3.556.1234
+func (p Patch) Bucket() Patch {
+	return Patch{Major: p.Major, Minor: p.Minor, parts: 2}
+}
+
+// SameBucket reports whether p and q belong to the same major.minor patch
+func (p Patch) SameBucket(q Patch) bool {
+	return p.Major == q.Major && p.Minor == q.Minor
+}
+
+// Bucket returns the major.minor patch of a game version, or the version as given
+// if it can't be parsed
+func Bucket(version string) string {
+	p, err := Parse(version)
+	if err != nil {
+		return version
+	}
+	return p.Bucket().String()
+}
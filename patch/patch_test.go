@@ -0,0 +1,90 @@
+package patch
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		version string
+		want    Patch
+	}{
+		{"14.3", Patch{Major: 14, Minor: 3, parts: 2}},
+		{"14.3.1", Patch{Major: 14, Minor: 3, Build: 1, parts: 3}},
+		{" 14.3.556.1234 ", Patch{Major: 14, Minor: 3, Build: 556, Revision: 1234, parts: 4}},
+	}
+	for _, tc := range cases {
+		got, err := Parse(tc.version)
+		if err != nil || got != tc.want {
+			t.Errorf("Parse(%q) = %+v, %v, want %+v", tc.version, got, err, tc.want)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, version := range []string{"", "14", "lolpatch_3.7", "14.x", "14.3.1.2.5", "-1.3", "14..3"} {
+		if p, err := Parse(version); err == nil {
+			t.Errorf("Parse(%q) = %+v, want an error", version, p)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	for _, version := range []string{"14.3", "14.3.1", "14.3.556.1234"} {
+		p, _ := Parse(version)
+		if got := p.String(); got != version {
+			t.Errorf("Parse(%q).String() = %q", version, got)
+		}
+	}
+	if got := (Patch{Major: 14, Minor: 3}).String(); got != "14.3" {
+		t.Errorf("zero-parts Patch.String() = %q, want 14.3", got)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		// Numeric, not lexical: 14.10 comes after 14.9
+		{"14.10", "14.9", 1},
+		{"14.9", "14.10", -1},
+		{"13.24", "14.1", -1},
+		{"14.3.556.1234", "14.3.556.1235", -1},
+		{"14.3.557.1", "14.3.556.9999", 1},
+		// Missing build parts count as zero
+		{"14.3", "14.3.0", 0},
+		{"14.3", "14.3.0.0", 0},
+		{"14.3", "14.3.1", -1},
+	}
+	for _, tc := range cases {
+		a, _ := Parse(tc.a)
+		b, _ := Parse(tc.b)
+		if got := a.Compare(b); got != tc.want {
+			t.Errorf("Compare(%s, %s) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+		if got := a.Less(b); got != (tc.want < 0) {
+			t.Errorf("Less(%s, %s) = %t", tc.a, tc.b, got)
+		}
+	}
+}
+
+func TestBucket(t *testing.T) {
+	cases := map[string]string{
+		"14.3.556.1234": "14.3",
+		"14.10.1":       "14.10",
+		"14.3":          "14.3",
+		// Versions that can't be parsed are given back as they are
+		"lolpatch_3.7": "lolpatch_3.7",
+	}
+	for version, want := range cases {
+		if got := Bucket(version); got != want {
+			t.Errorf("Bucket(%q) = %q, want %q", version, got, want)
+		}
+	}
+
+	a, _ := Parse("14.3.556.1234")
+	b, _ := Parse("14.3.1")
+	c, _ := Parse("14.4.1")
+	if !a.SameBucket(b) || a.SameBucket(c) {
+		t.Errorf("SameBucket: 14.3.556.1234 with 14.3.1 = %t, with 14.4.1 = %t", a.SameBucket(b), a.SameBucket(c))
+	}
+}
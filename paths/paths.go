@@ -0,0 +1,126 @@
+package paths
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// EnvDataDir - environment variable overriding the directory leaguestats keeps its files in
+const EnvDataDir = "LEAGUESTATS_DATA_DIR"
+
+const appName = "leaguestats"
+
+var dataDirOverride string
+
+// SetDataDir overrides the directory config and storage files are kept in (e.g. from --data-dir)
+func SetDataDir(dir string) {
+	dataDirOverride = dir
+}
+
+// overrideDir returns the directory set by flag or environment, if any
+func overrideDir() string {
+	if len(dataDirOverride) > 0 {
+		return dataDirOverride
+	}
+	return os.Getenv(EnvDataDir)
+}
+
+// ConfigDir returns the directory the config file is kept in
+// (e.g. ~/.config/leaguestats on Linux, %AppData%\leaguestats on Windows)
+func ConfigDir() string {
+	if dir := overrideDir(); len(dir) > 0 {
+		return dir
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return filepath.Join(dir, appName)
+}
+
+// DataDir returns the directory match storage is kept in
+// (e.g. ~/.cache/leaguestats on Linux, %LocalAppData%\leaguestats on Windows)
+func DataDir() string {
+	if dir := overrideDir(); len(dir) > 0 {
+		return dir
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return filepath.Join(dir, appName)
+}
+
+// ConfigFile returns the path of a file in the config directory, creating the
+// directory and migrating the file from its legacy location if necessary
+func ConfigFile(name string) string {
+	return resolve(ConfigDir(), name)
+}
+
+// DataFile returns the path of a file in the data directory, creating the
+// directory and migrating the file from its legacy location if necessary
+func DataFile(name string) string {
+	return resolve(DataDir(), name)
+}
+
+func resolve(dir, name string) string {
+	createDirIfNotExist(dir)
+	path := filepath.Join(dir, name)
+	migrateLegacyFile(name, path)
+	return path
+}
+
+func createDirIfNotExist(dir string) {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		err = os.MkdirAll(dir, 0755)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// legacyDir returns the directory files were kept in before paths were resolved
+// per OS. Outside Windows the backslashes made it a single oddly named directory.
+func legacyDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return home + "\\AppData\\Local\\leaguestats"
+}
+
+// migrateLegacyFile moves a file from the legacy directory to path, unless path already exists
+func migrateLegacyFile(name, path string) {
+	dir := legacyDir()
+	if len(dir) == 0 {
+		return
+	}
+	legacyPath := dir + "\\" + name
+	if filepath.Clean(legacyPath) == filepath.Clean(path) {
+		return
+	}
+	if _, err := os.Stat(path); err == nil {
+		return
+	}
+	if _, err := os.Stat(legacyPath); err != nil {
+		return
+	}
+
+	if err := os.Rename(legacyPath, path); err != nil {
+		// Rename fails across filesystems, so fall back to copying
+		b, err := ioutil.ReadFile(legacyPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err = ioutil.WriteFile(path, b, 0666); err != nil {
+			log.Fatal(err)
+		}
+		os.Remove(legacyPath)
+	}
+	log.Printf("Moved %s to %s\n", legacyPath, path)
+
+	// Only succeeds once every legacy file has been moved out
+	os.Remove(dir)
+}
@@ -0,0 +1,101 @@
+package paths
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// setupHome points the home and config directories into a temporary directory, without
+// a data directory override, returning the temporary directory
+func setupHome(t *testing.T) string {
+	root := t.TempDir()
+	home := filepath.Join(root, "home")
+	if err := os.Mkdir(home, 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(root, "config"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(root, "cache"))
+	t.Setenv(EnvDataDir, "")
+	SetDataDir("")
+	return root
+}
+
+// writeLegacyFile writes a file where it was kept before paths were resolved per OS,
+// returning its path
+func writeLegacyFile(t *testing.T, name, content string) string {
+	path := legacyDir() + "\\" + name
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func readFile(t *testing.T, path string) string {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestMigrateLegacyFile(t *testing.T) {
+	root := setupHome(t)
+	legacyPath := writeLegacyFile(t, "config.json", "legacy")
+
+	path := ConfigFile("config.json")
+	if want := filepath.Join(root, "config", appName, "config.json"); path != want {
+		t.Errorf("ConfigFile() = %s, want %s", path, want)
+	}
+	if got := readFile(t, path); got != "legacy" {
+		t.Errorf("migrated file holds %q, want the legacy file's \"legacy\"", got)
+	}
+	if _, err := os.Stat(legacyPath); !os.IsNotExist(err) {
+		t.Errorf("legacy file still present after the move: %v", err)
+	}
+}
+
+func TestMigrateLegacyFileKeepsDestination(t *testing.T) {
+	root := setupHome(t)
+	legacyPath := writeLegacyFile(t, "storage.json", "legacy")
+	dir := filepath.Join(root, "cache", appName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "storage.json"), []byte("current"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	path := DataFile("storage.json")
+	if got := readFile(t, path); got != "current" {
+		t.Errorf("existing file overwritten with %q", got)
+	}
+	if got := readFile(t, legacyPath); got != "legacy" {
+		t.Errorf("legacy file holds %q after a skipped move", got)
+	}
+}
+
+func TestDataDirOverride(t *testing.T) {
+	root := setupHome(t)
+	env := filepath.Join(root, "env")
+	t.Setenv(EnvDataDir, env)
+	if ConfigDir() != env || DataDir() != env {
+		t.Errorf("with %s set, ConfigDir() = %s and DataDir() = %s, want %s", EnvDataDir, ConfigDir(), DataDir(), env)
+	}
+
+	// --data-dir takes precedence over the environment
+	flag := filepath.Join(root, "flag")
+	SetDataDir(flag)
+	defer SetDataDir("")
+	if ConfigDir() != flag || DataDir() != flag {
+		t.Errorf("with SetDataDir, ConfigDir() = %s and DataDir() = %s, want %s", ConfigDir(), DataDir(), flag)
+	}
+	if path := DataFile("matches.db"); path != filepath.Join(flag, "matches.db") {
+		t.Errorf("DataFile() = %s, want it in %s", path, flag)
+	}
+	if _, err := os.Stat(flag); err != nil {
+		t.Errorf("data directory not created: %v", err)
+	}
+}
@@ -0,0 +1,195 @@
+package render
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Format - an output format results can be rendered in
+type Format string
+
+// Output formats
+const (
+	Table    Format = "table"
+	JSON     Format = "json"
+	NDJSON   Format = "ndjson"
+	CSV      Format = "csv"
+	Markdown Format = "markdown"
+)
+
+// Formats returns every supported output format
+func Formats() []Format {
+	return []Format{Table, JSON, NDJSON, CSV, Markdown}
+}
+
+// FormatNames returns the supported output formats as a comma-separated list, for flag help
+func FormatNames() string {
+	var names []string
+	for _, f := range Formats() {
+		names = append(names, string(f))
+	}
+	return strings.Join(names, ", ")
+}
+
+// ParseFormat parses an output format name (e.g. from --format)
+func ParseFormat(name string) (Format, error) {
+	for _, f := range Formats() {
+		if string(f) == strings.ToLower(name) {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unknown format %q (want one of %s)", name, FormatNames())
+}
+
+// Tabular - results that can be rendered in every Format. Columns and Rows
+// give the text cells used by table, CSV and Markdown output; Records gives
+// the values encoded by JSON and NDJSON output, one per row.
+type Tabular interface {
+	Columns() []string
+	Rows() [][]string
+	Records() []interface{}
+}
+
+// Write renders t to w in the format f
+func Write(w io.Writer, f Format, t Tabular) error {
+	switch f {
+	case Table:
+		return writeTable(w, t)
+	case JSON:
+		return writeJSON(w, t)
+	case NDJSON:
+		return writeNDJSON(w, t)
+	case CSV:
+		return writeCSV(w, t)
+	case Markdown:
+		return writeMarkdown(w, t)
+	}
+	return fmt.Errorf("unknown format %q", f)
+}
+
+// writeTable writes aligned columns
+func writeTable(w io.Writer, t Tabular) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(t.Columns(), "\t"))
+	for _, row := range t.Rows() {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// writeJSON writes the records as one indented array
+func writeJSON(w io.Writer, t Tabular) error {
+	records := t.Records()
+	if records == nil {
+		records = []interface{}{}
+	}
+	b, err := json.MarshalIndent(records, "", "    ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// writeNDJSON writes one record per line
+func writeNDJSON(w io.Writer, t Tabular) error {
+	enc := json.NewEncoder(w)
+	for _, record := range t.Records() {
+		if err := enc.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeCSV writes a header line followed by the rows
+func writeCSV(w io.Writer, t Tabular) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(t.Columns()); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(t.Rows()); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// writeMarkdown writes a GitHub-flavored Markdown table
+func writeMarkdown(w io.Writer, t Tabular) error {
+	columns := t.Columns()
+	separators := make([]string, len(columns))
+	for i := range separators {
+		separators[i] = "---"
+	}
+	lines := []string{markdownRow(columns), markdownRow(separators)}
+	for _, row := range t.Rows() {
+		lines = append(lines, markdownRow(row))
+	}
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
+
+func markdownRow(cells []string) string {
+	escaped := make([]string, len(cells))
+	for i, cell := range cells {
+		escaped[i] = strings.Replace(cell, "|", "\\|", -1)
+	}
+	return "| " + strings.Join(escaped, " | ") + " |"
+}
+
+// Section - one of the tables of a report made of several
+type Section struct {
+	Name    string
+	Results Tabular
+}
+
+// WriteSections renders a report made of several tables to w in the format f as one
+// document: JSON as an object holding the records of each section under its name,
+// NDJSON as that object on one line, Markdown as a table under a heading for each
+// section and table output as one table after another. A CSV file holds one table
+// only, so CSV output takes a single section.
+func WriteSections(w io.Writer, f Format, sections []Section) error {
+	if len(sections) == 1 {
+		return Write(w, f, sections[0].Results)
+	}
+	switch f {
+	case Table, Markdown:
+		for i, section := range sections {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			if f == Markdown {
+				fmt.Fprintf(w, "## %s\n\n", section.Name)
+			}
+			if err := Write(w, f, section.Results); err != nil {
+				return err
+			}
+		}
+		return nil
+	case JSON, NDJSON:
+		document := make(map[string][]interface{})
+		for _, section := range sections {
+			records := section.Results.Records()
+			if records == nil {
+				records = []interface{}{}
+			}
+			document[section.Name] = records
+		}
+		if f == NDJSON {
+			return json.NewEncoder(w).Encode(document)
+		}
+		b, err := json.MarshalIndent(document, "", "    ")
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(b, '\n'))
+		return err
+	case CSV:
+		return fmt.Errorf("%s output holds one table, not %d", f, len(sections))
+	}
+	return fmt.Errorf("unknown format %q", f)
+}
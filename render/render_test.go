@@ -0,0 +1,166 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// update rewrites the golden files from the current output: go test ./render -update
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// champion - a test record
+type champion struct {
+	Name    string
+	Games   int64
+	WinRate float64
+}
+
+// champions - test results
+type champions []champion
+
+func (cs champions) Columns() []string {
+	return []string{"Champion", "Games", "Win Rate"}
+}
+
+func (cs champions) Rows() [][]string {
+	var rows [][]string
+	for _, c := range cs {
+		rows = append(rows, []string{c.Name, strconv.FormatInt(c.Games, 10), strconv.FormatFloat(c.WinRate, 'f', 3, 64)})
+	}
+	return rows
+}
+
+func (cs champions) Records() []interface{} {
+	var records []interface{}
+	for _, c := range cs {
+		records = append(records, c)
+	}
+	return records
+}
+
+var goldenCases = []struct {
+	name    string
+	results champions
+}{
+	{"champions", champions{
+		{"Kai'Sa", 12, 0.583},
+		{"Nunu & Willump", 3, 0.333},
+		// Pipes must be escaped in Markdown, and commas and quotes quoted in CSV
+		{`Left|Right, "quoted"`, 1, 1},
+	}},
+	{"empty", nil},
+}
+
+func TestWriteGolden(t *testing.T) {
+	for _, tc := range goldenCases {
+		for _, f := range Formats() {
+			t.Run(tc.name+"/"+string(f), func(t *testing.T) {
+				var buf bytes.Buffer
+				if err := Write(&buf, f, tc.results); err != nil {
+					t.Fatal(err)
+				}
+				golden := filepath.Join("testdata", tc.name+"."+string(f))
+				if *update {
+					if err := ioutil.WriteFile(golden, buf.Bytes(), 0644); err != nil {
+						t.Fatal(err)
+					}
+				}
+				want, err := ioutil.ReadFile(golden)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(buf.Bytes(), want) {
+					t.Errorf("output differs from %s:\n%s\nwant:\n%s", golden, buf.Bytes(), want)
+				}
+			})
+		}
+	}
+}
+
+func TestWriteSectionsGolden(t *testing.T) {
+	sections := []Section{
+		{"Champions", goldenCases[0].results},
+		{"Empty", goldenCases[1].results},
+	}
+	for _, f := range Formats() {
+		if f == CSV {
+			continue
+		}
+		t.Run(string(f), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteSections(&buf, f, sections); err != nil {
+				t.Fatal(err)
+			}
+			golden := filepath.Join("testdata", "sections."+string(f))
+			if *update {
+				if err := ioutil.WriteFile(golden, buf.Bytes(), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := ioutil.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(buf.Bytes(), want) {
+				t.Errorf("output differs from %s:\n%s\nwant:\n%s", golden, buf.Bytes(), want)
+			}
+		})
+	}
+}
+
+func TestWriteSectionsOneDocument(t *testing.T) {
+	sections := []Section{{"Champions", goldenCases[0].results}, {"Empty", champions{}}}
+	for _, f := range []Format{JSON, NDJSON} {
+		var buf bytes.Buffer
+		if err := WriteSections(&buf, f, sections); err != nil {
+			t.Fatal(err)
+		}
+		var document map[string][]champion
+		dec := json.NewDecoder(&buf)
+		if err := dec.Decode(&document); err != nil {
+			t.Fatalf("%s output is not one JSON document: %v", f, err)
+		}
+		if dec.More() {
+			t.Errorf("%s output holds more than one document", f)
+		}
+		if len(document["Champions"]) != 3 || document["Empty"] == nil {
+			t.Errorf("%s document = %v", f, document)
+		}
+	}
+	if err := WriteSections(&bytes.Buffer{}, CSV, sections); err == nil {
+		t.Error("WriteSections of two sections as CSV succeeded")
+	}
+	// A single section is written as it would be on its own
+	var one, alone bytes.Buffer
+	if err := WriteSections(&one, CSV, sections[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := Write(&alone, CSV, sections[0].Results); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(one.Bytes(), alone.Bytes()) {
+		t.Errorf("single section output = %s, want %s", one.Bytes(), alone.Bytes())
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, f := range Formats() {
+		if got, err := ParseFormat(string(f)); err != nil || got != f {
+			t.Errorf("ParseFormat(%q) = %q, %v", f, got, err)
+		}
+	}
+	if got, err := ParseFormat("JSON"); err != nil || got != JSON {
+		t.Errorf("ParseFormat(\"JSON\") = %q, %v, want json", got, err)
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Error("ParseFormat(\"xml\") succeeded")
+	}
+	if err := Write(&bytes.Buffer{}, Format("xml"), champions{}); err == nil {
+		t.Error("Write in an unknown format succeeded")
+	}
+}
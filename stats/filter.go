@@ -0,0 +1,224 @@
+package stats
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/WhiteAcres/leaguestats/client"
+	"github.com/WhiteAcres/leaguestats/patch"
+	"github.com/WhiteAcres/leaguestats/storage"
+)
+
+// Queue IDs of the common queues
+const (
+	QueueNormalDraft int64 = 400
+	QueueRankedSolo  int64 = 420
+	QueueNormalBlind int64 = 430
+	QueueRankedFlex  int64 = 440
+	QueueARAM        int64 = 450
+	QueueQuickplay   int64 = 490
+	QueueClash       int64 = 700
+)
+
+// queueNames maps the names ParseQueues accepts onto queue IDs
+var queueNames = map[string][]int64{
+	"solo":      {QueueRankedSolo},
+	"flex":      {QueueRankedFlex},
+	"ranked":    {QueueRankedSolo, QueueRankedFlex},
+	"draft":     {QueueNormalDraft},
+	"blind":     {QueueNormalBlind},
+	"quickplay": {QueueQuickplay},
+	"normal":    {QueueNormalDraft, QueueNormalBlind, QueueQuickplay},
+	"aram":      {QueueARAM},
+	"clash":     {QueueClash},
+}
+
+// RemakeDuration is a game length remakes end before, for MatchFilter.MinDuration
+const RemakeDuration = 5 * time.Minute
+
+// botQueues are the co-op vs. AI queues, left out of Summoner's Rift stats
+var botQueues = map[int64]bool{830: true, 840: true, 850: true, 870: true, 880: true, 890: true}
+
+// summonersRiftMapID is the map ID of Summoner's Rift
+const summonersRiftMapID = 11
+
+// ParseQueues parses a comma-separated list of queue IDs or names (solo, flex,
+// ranked, draft, blind, quickplay, normal, aram, clash), e.g. from --queue
+func ParseQueues(list string) ([]int64, error) {
+	var queueIDs []int64
+	for _, name := range strings.Split(list, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if len(name) == 0 {
+			continue
+		}
+		if ids, ok := queueNames[name]; ok {
+			queueIDs = append(queueIDs, ids...)
+			continue
+		}
+		queueID, err := strconv.ParseInt(name, 10, 64)
+		if err != nil || queueID <= 0 {
+			return nil, fmt.Errorf("unknown queue %q (want a queue ID or one of solo, flex, ranked, draft, blind, quickplay, normal, aram, clash)", name)
+		}
+		queueIDs = append(queueIDs, queueID)
+	}
+	return queueIDs, nil
+}
+
+// ParseRole parses a position such as mid or support into one of client.Positions()
+func ParseRole(name string) (string, error) {
+	switch strings.ToUpper(strings.TrimSpace(name)) {
+	case "TOP":
+		return "TOP", nil
+	case "JUNGLE", "JG", "JGL":
+		return "JUNGLE", nil
+	case "MIDDLE", "MID":
+		return "MIDDLE", nil
+	case "BOTTOM", "BOT", "ADC", "CARRY":
+		return "BOTTOM", nil
+	case "UTILITY", "SUPPORT", "SUP", "SUPP":
+		return "UTILITY", nil
+	}
+	return "", fmt.Errorf("unknown role %q (want top, jungle, mid, bot or support)", name)
+}
+
+// MatchFilter - narrows down the matches a report is made from. Zero fields match
+// everything, and the fields combine so a match has to satisfy all of them.
+type MatchFilter struct {
+	// QueueIDs matches games in any of the queues
+	QueueIDs []int64
+	// MinPatch and MaxPatch bound the patch of the game version, inclusive, e.g.
+	// "14.1" to "14.3"; a longer version such as "14.3.1" stands for its patch
+	MinPatch string
+	MaxPatch string
+	// Since and Until bound when the game was created; Until is exclusive
+	Since time.Time
+	Until time.Time
+	// ChampionID matches games in which the summoner played the champion
+	ChampionID int64
+	// Role matches games in which the summoner played the position, one of client.Positions()
+	Role string
+	// MinDuration leaves out shorter games, e.g. remakes
+	MinDuration time.Duration
+}
+
+// Validate checks that the filter's patches can be parsed and its role is known
+func (f MatchFilter) Validate() error {
+	for _, version := range []string{f.MinPatch, f.MaxPatch} {
+		if len(version) == 0 {
+			continue
+		}
+		if _, err := patch.Parse(version); err != nil {
+			return err
+		}
+	}
+	if len(f.Role) > 0 {
+		if _, err := ParseRole(f.Role); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// query returns the part of the filter the store can apply itself
+func (f MatchFilter) query(playerID string) storage.MatchQuery {
+	q := storage.MatchQuery{PlayerID: playerID, QueueIDs: f.QueueIDs, ChampionID: f.ChampionID}
+	if len(f.MinPatch) > 0 && patch.Bucket(f.MinPatch) == patch.Bucket(f.MaxPatch) {
+		q.Patch = f.MinPatch
+	}
+	return q
+}
+
+// Match checks if a match of the summoner satisfies the filter
+func (f MatchFilter) Match(playerID string, match client.Match) bool {
+	if len(f.QueueIDs) > 0 {
+		inQueue := false
+		for _, queueID := range f.QueueIDs {
+			if match.Info.QueueID == queueID {
+				inQueue = true
+			}
+		}
+		if !inQueue {
+			return false
+		}
+	}
+	if len(f.MinPatch) > 0 || len(f.MaxPatch) > 0 {
+		p, err := patch.Parse(match.Info.GameVersion)
+		if err != nil {
+			return false
+		}
+		if min, err := patch.Parse(f.MinPatch); err == nil && p.Bucket().Less(min.Bucket()) {
+			return false
+		}
+		if max, err := patch.Parse(f.MaxPatch); err == nil && max.Bucket().Less(p.Bucket()) {
+			return false
+		}
+	}
+	created := time.Unix(0, match.Info.GameCreation*int64(time.Millisecond))
+	if !f.Since.IsZero() && created.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !created.Before(f.Until) {
+		return false
+	}
+	if f.MinDuration > 0 && time.Duration(match.GameDurationSeconds())*time.Second < f.MinDuration {
+		return false
+	}
+	if f.ChampionID != 0 || len(f.Role) > 0 {
+		participant, ok := getSummonerParticipant(playerID, match)
+		if !ok {
+			return false
+		}
+		if f.ChampionID != 0 && participant.ChampionID != f.ChampionID {
+			return false
+		}
+		if len(f.Role) > 0 {
+			role, _ := ParseRole(f.Role)
+			if participant.Position() != role {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// FindChampionID returns the ID of a champion given by ID or by name, ignoring case,
+// spaces and punctuation (e.g. "kaisa" for Kai'Sa)
+func FindChampionID(s storage.Store, name string) (int64, error) {
+	if championID, err := strconv.ParseInt(name, 10, 64); err == nil {
+		return championID, nil
+	}
+	championNamesMap, err := GetChampionNames(s)
+	if err != nil {
+		return 0, err
+	}
+	for championID, championName := range championNamesMap {
+		if normalizeChampionName(championName) == normalizeChampionName(name) {
+			return championID, nil
+		}
+	}
+	// Without Data Dragon, fall back on the names stored in matches
+	matches, err := GetMatches(s)
+	if err != nil {
+		return 0, err
+	}
+	for _, match := range matches {
+		for _, participant := range match.Info.Participants {
+			if normalizeChampionName(participant.ChampionName) == normalizeChampionName(name) {
+				return participant.ChampionID, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unknown champion %q", name)
+}
+
+func normalizeChampionName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
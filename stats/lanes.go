@@ -0,0 +1,91 @@
+package stats
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/WhiteAcres/leaguestats/client"
+	"github.com/WhiteAcres/leaguestats/storage"
+)
+
+// LaneBanRecommendation - a ban recommendation against the summoner's lane opponents
+// in one position
+type LaneBanRecommendation struct {
+	// Position is the position the summoner played, one of client.Positions()
+	Position string
+	BanRecommendation
+}
+
+// LaneBanRecommendations - ban recommendations per position, in the order of
+// client.Positions() and best ban first within each
+type LaneBanRecommendations []LaneBanRecommendation
+
+// Columns returns the table header of the recommendations
+func (lbrs LaneBanRecommendations) Columns() []string {
+	return []string{"Position", "Champion", "Times Faced", "Defeats", "Enemy Win Rate", "Interval", "Baseline", "Ban Score"}
+}
+
+// Rows returns the table cells of the recommendations
+func (lbrs LaneBanRecommendations) Rows() [][]string {
+	var rows [][]string
+	for _, lbr := range lbrs {
+		rows = append(rows, []string{
+			lbr.Position,
+			lbr.Name,
+			strconv.FormatInt(lbr.TotalMatches, 10),
+			strconv.FormatInt(lbr.Victories, 10),
+			fmt.Sprintf("%.3f", lbr.WinRate),
+			fmt.Sprintf("%.3f-%.3f", lbr.WinRateLower, lbr.WinRateUpper),
+			fmt.Sprintf("%.3f", lbr.Baseline),
+			fmt.Sprintf("%+.3f", lbr.BanScore),
+		})
+	}
+	return rows
+}
+
+// Records returns the recommendations for JSON encoding
+func (lbrs LaneBanRecommendations) Records() []interface{} {
+	var records []interface{}
+	for _, lbr := range lbrs {
+		records = append(records, lbr)
+	}
+	return records
+}
+
+// GetLaneBansForSummoner gets ban recommendations for each position the summoner
+// played, from their games in that position that satisfy the filter, counting only
+// their lane opponents so a mid laner's bans aren't swayed by the enemy support. At
+// most perLane champions are kept for each position (all of them if perLane is 0).
+// The baseline of a position is the summoner's loss rate in it.
+func GetLaneBansForSummoner(s storage.Store, playerID string, opts BanOptions, perLane int, filter MatchFilter) (LaneBanRecommendations, error) {
+	summonerMatches, err := GetMatchesForSummoner(s, playerID, filter)
+	if err != nil {
+		return nil, err
+	}
+	championNamesMap, err := GetChampionNames(s)
+	if err != nil {
+		return nil, err
+	}
+
+	matchesByPosition := make(map[string][]client.Match)
+	for _, match := range GetSRMatches(summonerMatches) {
+		summoner, ok := getSummonerParticipant(playerID, match)
+		if !ok {
+			continue
+		}
+		position := summoner.Position()
+		matchesByPosition[position] = append(matchesByPosition[position], match)
+	}
+
+	var laneBans LaneBanRecommendations
+	for _, position := range client.Positions() {
+		recommendations := banRecommendations(playerID, matchesByPosition[position], championNamesMap, opts, true)
+		for i, br := range recommendations {
+			if perLane > 0 && i >= perLane {
+				break
+			}
+			laneBans = append(laneBans, LaneBanRecommendation{Position: position, BanRecommendation: br})
+		}
+	}
+	return laneBans, nil
+}
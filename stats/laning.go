@@ -0,0 +1,328 @@
+package stats
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/WhiteAcres/leaguestats/client"
+	"github.com/WhiteAcres/leaguestats/storage"
+)
+
+// Times into the game the laning differentials are taken at
+const (
+	laningEarly = 10 * time.Minute
+	laningLate  = 15 * time.Minute
+)
+
+// Differential - the summoner's lead over their lane opponent at one point of a game.
+// Negative values are a deficit.
+type Differential struct {
+	Gold int64
+	XP   int64
+	CS   int64
+}
+
+// LaningStats - how the summoner's laning phase went in one match
+type LaningStats struct {
+	MatchID  string
+	Champion string
+	Position string
+	Opponent string
+	// At10 and At15 are nil if the game ended before then
+	At10 *Differential
+	At15 *Differential
+	// FirstBlood is when the game's first champion kill happened, and FirstBloodRole
+	// whether the summoner took part: "kill", "assist", "death" or ""
+	FirstBlood     time.Duration
+	FirstBloodRole string
+	// FirstTower is when the game's first tower fell, and FirstTowerAlly whether the
+	// summoner's team took it
+	FirstTower     time.Duration
+	FirstTowerAlly bool
+	// Deaths are every death of the summoner in the game
+	Deaths []Death
+}
+
+// Death - where and when the summoner died
+type Death struct {
+	MatchID string
+	Time    time.Duration
+	X       int64
+	Y       int64
+	// Zone is the part of Summoner's Rift, seen from the summoner's side; see mapZone
+	Zone string
+	// Killer is the champion that got the kill, or "" if the summoner was executed
+	Killer string
+}
+
+// LaningStatsList - the summoner's laning phases, most recent first
+type LaningStatsList []LaningStats
+
+// Columns returns the table header of the laning phases
+func (lsl LaningStatsList) Columns() []string {
+	return []string{"Match", "Champion", "Opponent", "Gold@10", "XP@10", "CS@10", "Gold@15", "XP@15", "CS@15", "First Blood", "First Tower", "Deaths <15"}
+}
+
+// Rows returns the table cells of the laning phases
+func (lsl LaningStatsList) Rows() [][]string {
+	var rows [][]string
+	for _, ls := range lsl {
+		row := []string{ls.MatchID, ls.Champion, ls.Opponent}
+		for _, diff := range []*Differential{ls.At10, ls.At15} {
+			if diff == nil {
+				row = append(row, "-", "-", "-")
+				continue
+			}
+			row = append(row, fmt.Sprintf("%+d", diff.Gold), fmt.Sprintf("%+d", diff.XP), fmt.Sprintf("%+d", diff.CS))
+		}
+		firstBlood := "-"
+		if ls.FirstBlood > 0 {
+			firstBlood = formatGameTime(ls.FirstBlood)
+			if len(ls.FirstBloodRole) > 0 {
+				firstBlood += " " + ls.FirstBloodRole
+			}
+		}
+		firstTower := "-"
+		if ls.FirstTower > 0 {
+			firstTower = formatGameTime(ls.FirstTower) + " enemy"
+			if ls.FirstTowerAlly {
+				firstTower = formatGameTime(ls.FirstTower) + " ally"
+			}
+		}
+		earlyDeaths := 0
+		for _, death := range ls.Deaths {
+			if death.Time < laningLate {
+				earlyDeaths++
+			}
+		}
+		row = append(row, firstBlood, firstTower, strconv.Itoa(earlyDeaths))
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// Records returns the laning phases for JSON encoding
+func (lsl LaningStatsList) Records() []interface{} {
+	var records []interface{}
+	for _, ls := range lsl {
+		records = append(records, ls)
+	}
+	return records
+}
+
+// Deaths - the summoner's deaths over several matches
+type Deaths []Death
+
+// Columns returns the table header of the deaths
+func (ds Deaths) Columns() []string {
+	return []string{"Match", "Time", "Zone", "X", "Y", "Killer"}
+}
+
+// Rows returns the table cells of the deaths
+func (ds Deaths) Rows() [][]string {
+	var rows [][]string
+	for _, d := range ds {
+		rows = append(rows, []string{
+			d.MatchID,
+			formatGameTime(d.Time),
+			d.Zone,
+			strconv.FormatInt(d.X, 10),
+			strconv.FormatInt(d.Y, 10),
+			d.Killer,
+		})
+	}
+	return rows
+}
+
+// Records returns the deaths for JSON encoding
+func (ds Deaths) Records() []interface{} {
+	var records []interface{}
+	for _, d := range ds {
+		records = append(records, d)
+	}
+	return records
+}
+
+// formatGameTime formats a time into the game as minutes and seconds, e.g. 12:05
+func formatGameTime(d time.Duration) string {
+	seconds := int64(d / time.Second)
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+// GetLaningStatsForSummoner gets the summoner's laning phase in each of their games that
+// satisfy the filter and have a stored timeline, most recent first
+func GetLaningStatsForSummoner(s storage.Store, playerID string, filter MatchFilter) (LaningStatsList, error) {
+	matches, err := GetMatchesForSummoner(s, playerID, filter)
+	if err != nil {
+		return nil, err
+	}
+	matchIDs := make([]string, len(matches))
+	for i, match := range matches {
+		matchIDs[i] = match.Metadata.MatchID
+	}
+	timelines, err := s.Timelines(matchIDs)
+	if err != nil {
+		return nil, err
+	}
+	championNamesMap, err := GetChampionNames(s)
+	if err != nil {
+		return nil, err
+	}
+
+	var laningStats LaningStatsList
+	for _, match := range matches {
+		timeline, ok := timelines[match.Metadata.MatchID]
+		if !ok {
+			continue
+		}
+		participant, ok := getSummonerParticipant(playerID, match)
+		if !ok {
+			continue
+		}
+		laningStats = append(laningStats, laningPhase(match, *timeline, participant, championNamesMap))
+	}
+	return laningStats, nil
+}
+
+// GetDeathsForSummoner gets where the summoner died in each of their games that satisfy
+// the filter and have a stored timeline
+func GetDeathsForSummoner(s storage.Store, playerID string, filter MatchFilter) (Deaths, error) {
+	laningStats, err := GetLaningStatsForSummoner(s, playerID, filter)
+	if err != nil {
+		return nil, err
+	}
+	var deaths Deaths
+	for _, ls := range laningStats {
+		deaths = append(deaths, ls.Deaths...)
+	}
+	return deaths, nil
+}
+
+// laningPhase works out the participant's laning phase from a match and its timeline
+func laningPhase(match client.Match, timeline client.Timeline, participant client.Participant, championNamesMap map[int64]string) LaningStats {
+	ls := LaningStats{
+		MatchID:  match.Metadata.MatchID,
+		Champion: championName(championNamesMap, participant),
+		Position: participant.Position(),
+	}
+	if opponent, ok := match.LaneOpponent(participant); ok {
+		ls.Opponent = championName(championNamesMap, opponent)
+		ls.At10 = differentialAt(timeline, laningEarly, participant.ParticipantID, opponent.ParticipantID)
+		ls.At15 = differentialAt(timeline, laningLate, participant.ParticipantID, opponent.ParticipantID)
+	}
+
+	participants := make(map[int64]client.Participant)
+	for _, p := range match.Info.Participants {
+		participants[p.ParticipantID] = p
+	}
+	kills := timeline.Events(client.EventChampionKill)
+	if len(kills) > 0 {
+		firstBlood := kills[0]
+		ls.FirstBlood = time.Duration(firstBlood.Timestamp) * time.Millisecond
+		for _, assistID := range firstBlood.AssistingParticipantIDs {
+			if assistID == participant.ParticipantID {
+				ls.FirstBloodRole = "assist"
+			}
+		}
+		// Getting or giving up the kill counts over an assist, should Riot list both
+		switch participant.ParticipantID {
+		case firstBlood.KillerID:
+			ls.FirstBloodRole = "kill"
+		case firstBlood.VictimID:
+			ls.FirstBloodRole = "death"
+		}
+	}
+	for _, building := range timeline.Events(client.EventBuildingKill) {
+		if building.BuildingType == "TOWER_BUILDING" {
+			ls.FirstTower = time.Duration(building.Timestamp) * time.Millisecond
+			// TeamID is the team that lost the tower
+			ls.FirstTowerAlly = building.TeamID != participant.TeamID
+			break
+		}
+	}
+	for _, kill := range kills {
+		if kill.VictimID != participant.ParticipantID {
+			continue
+		}
+		death := Death{
+			MatchID: match.Metadata.MatchID,
+			Time:    time.Duration(kill.Timestamp) * time.Millisecond,
+		}
+		if kill.Position != nil {
+			death.X, death.Y = kill.Position.X, kill.Position.Y
+			if isSR(match) {
+				death.Zone = mapZone(death.X, death.Y, participant.TeamID)
+			}
+		}
+		if killer, ok := participants[kill.KillerID]; ok {
+			death.Killer = championName(championNamesMap, killer)
+		}
+		ls.Deaths = append(ls.Deaths, death)
+	}
+	return ls
+}
+
+// differentialAt returns how far the participant was ahead of the opponent at the time
+// into the game, or nil if the game ended before it
+func differentialAt(timeline client.Timeline, at time.Duration, participantID, opponentID int64) *Differential {
+	frame, ok := timeline.FrameAt(int64(at / time.Millisecond))
+	if !ok {
+		return nil
+	}
+	pf, ok := frame.ParticipantFrame(participantID)
+	if !ok {
+		return nil
+	}
+	of, ok := frame.ParticipantFrame(opponentID)
+	if !ok {
+		return nil
+	}
+	return &Differential{
+		Gold: pf.TotalGold - of.TotalGold,
+		XP:   pf.XP - of.XP,
+		CS:   (pf.MinionsKilled + pf.JungleMinionsKilled) - (of.MinionsKilled + of.JungleMinionsKilled),
+	}
+}
+
+// Summoner's Rift is about 14,900 units square, blue side (team 100) in the bottom left
+// and red side (team 200) in the top right; mid lane runs along x = y and the river
+// along x + y = riftRiver
+const (
+	riftRiver     = 14800
+	riftLaneWidth = 2000
+	riftBase      = 4500
+)
+
+// mapZone names the part of Summoner's Rift a point is in, roughly: "top", "mid", "bot",
+// "river", or "ally"/"enemy" followed by "base" or "jungle" from the side of teamID
+func mapZone(x, y, teamID int64) string {
+	side := func(blue bool) string {
+		if blue == (teamID == 100) {
+			return "ally"
+		}
+		return "enemy"
+	}
+	switch {
+	case x < riftBase && y < riftBase:
+		return side(true) + " base"
+	case x > riftRiver-riftBase && y > riftRiver-riftBase:
+		return side(false) + " base"
+	case x < riftLaneWidth || y > riftRiver-riftLaneWidth:
+		return "top"
+	case y < riftLaneWidth || x > riftRiver-riftLaneWidth:
+		return "bot"
+	case abs(x-y) < riftLaneWidth/2:
+		return "mid"
+	case abs(x+y-riftRiver) < riftLaneWidth/2:
+		return "river"
+	}
+	return side(x+y < riftRiver) + " jungle"
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
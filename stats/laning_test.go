@@ -0,0 +1,136 @@
+package stats
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/WhiteAcres/leaguestats/client"
+)
+
+// loadTimeline reads the timeline of a game that ended at 14:00, whose 10:00 frame is
+// stamped just after and whose first blood is an assist of the blue mid laner
+func loadTimeline(t *testing.T) client.Timeline {
+	b, err := ioutil.ReadFile(filepath.Join("testdata", "timeline.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var timeline client.Timeline
+	if err = json.Unmarshal(b, &timeline); err != nil {
+		t.Fatal(err)
+	}
+	return timeline
+}
+
+// laningMatch is the match of the timeline: Ahri and Zed in mid, and a blue bot laner
+func laningMatch() client.Match {
+	return *testMatch("NA1_300", "14.3.1", 1,
+		client.Participant{ParticipantID: 1, PuuID: "puuid-mid", TeamID: 100, TeamPosition: "MIDDLE", ChampionID: 103, ChampionName: "Ahri"},
+		client.Participant{ParticipantID: 2, PuuID: "puuid-bot", TeamID: 100, TeamPosition: "BOTTOM", ChampionID: 222, ChampionName: "Jinx"},
+		client.Participant{ParticipantID: 6, PuuID: "puuid-enemy-mid", TeamID: 200, TeamPosition: "MIDDLE", ChampionID: 238, ChampionName: "Zed"},
+	)
+}
+
+func TestLaningPhase(t *testing.T) {
+	timeline := loadTimeline(t)
+	match := laningMatch()
+	mid := match.Info.Participants[0]
+
+	ls := laningPhase(match, timeline, mid, nil)
+	if ls.Champion != "Ahri" || ls.Opponent != "Zed" || ls.Position != "MIDDLE" {
+		t.Errorf("laning phase of %s = %+v", ls.Champion, ls)
+	}
+	// The 10:00 differential comes from the frame stamped 10:00.012, not the one at 9:00
+	if ls.At10 == nil || *ls.At10 != (Differential{Gold: 500, XP: 200, CS: 14}) {
+		t.Errorf("At10 = %+v, want 500 gold, 200 XP and 14 CS ahead", ls.At10)
+	}
+	if ls.At15 != nil {
+		t.Errorf("At15 = %+v for a game that ended at 14:00, want nil", ls.At15)
+	}
+	if ls.FirstBlood != 3*time.Minute || ls.FirstBloodRole != "assist" {
+		t.Errorf("first blood = %s %q, want an assist at 3:00", ls.FirstBlood, ls.FirstBloodRole)
+	}
+	// The inhibitor event before it isn't a tower; blue took red's tower
+	if ls.FirstTower != 595*time.Second || !ls.FirstTowerAlly {
+		t.Errorf("first tower = %s, ally %t, want blue's at 9:55", ls.FirstTower, ls.FirstTowerAlly)
+	}
+	if len(ls.Deaths) != 2 {
+		t.Fatalf("deaths = %+v, want 2", ls.Deaths)
+	}
+	if d := ls.Deaths[0]; d.Killer != "Zed" || d.Zone != "mid" || d.Time != 400*time.Second {
+		t.Errorf("first death = %+v, want to Zed in mid at 6:40", d)
+	}
+	if d := ls.Deaths[1]; d.Killer != "" || d.Zone != "enemy base" {
+		t.Errorf("second death = %+v, want executed in the enemy base", d)
+	}
+
+	// Red lost that tower, and the bot laner gave up first blood
+	zed := laningPhase(match, timeline, match.Info.Participants[2], nil)
+	if zed.FirstTowerAlly || zed.FirstBloodRole != "kill" {
+		t.Errorf("Zed's first tower ally %t and first blood %q, want false and kill", zed.FirstTowerAlly, zed.FirstBloodRole)
+	}
+	if zed.At10 == nil || *zed.At10 != (Differential{Gold: -500, XP: -200, CS: -14}) {
+		t.Errorf("Zed's At10 = %+v, want Ahri's lead as a deficit", zed.At10)
+	}
+	if bot := laningPhase(match, timeline, match.Info.Participants[1], nil); bot.FirstBloodRole != "death" || bot.At10 != nil {
+		t.Errorf("bot laner without an opponent = %+v, want first blood given up and no differential", bot)
+	}
+
+	// Killing or dying counts over also being listed as assisting
+	timeline.Info.Frames[1].Events[0].AssistingParticipantIDs = []int64{1, 2, 6}
+	if role := laningPhase(match, timeline, match.Info.Participants[1], nil).FirstBloodRole; role != "death" {
+		t.Errorf("first blood role of the victim also listed as assisting = %q, want death", role)
+	}
+	if role := laningPhase(match, timeline, match.Info.Participants[2], nil).FirstBloodRole; role != "kill" {
+		t.Errorf("first blood role of the killer also listed as assisting = %q, want kill", role)
+	}
+}
+
+func TestDifferentialAt(t *testing.T) {
+	timeline := loadTimeline(t)
+	// The last frame is taken at the end of the game
+	if d := differentialAt(timeline, 14*time.Minute, 1, 6); d == nil || *d != (Differential{Gold: -200, XP: -100, CS: -5}) {
+		t.Errorf("differential at the last frame = %+v", d)
+	}
+	if d := differentialAt(timeline, 14*time.Minute+time.Second, 1, 6); d != nil {
+		t.Errorf("differential after the game ended = %+v, want nil", d)
+	}
+	// A participant missing from the frame has no differential
+	if d := differentialAt(timeline, 10*time.Minute, 1, 7); d != nil {
+		t.Errorf("differential against a missing participant = %+v, want nil", d)
+	}
+}
+
+func TestMapZone(t *testing.T) {
+	cases := []struct {
+		x, y, teamID int64
+		zone         string
+	}{
+		{500, 500, 100, "ally base"},
+		{500, 500, 200, "enemy base"},
+		{14000, 14000, 100, "enemy base"},
+		{14000, 14000, 200, "ally base"},
+		// Bases end at riftBase, where mid lane runs on
+		{4499, 4499, 100, "ally base"},
+		{4500, 4500, 100, "mid"},
+		{1999, 8000, 100, "top"},
+		{8000, 12801, 100, "top"},
+		{8000, 1999, 200, "bot"},
+		{12801, 6000, 200, "bot"},
+		{7000, 7999, 100, "mid"},
+		{7000, 8000, 100, "river"},
+		{5000, 9799, 100, "river"},
+		{5000, 6000, 100, "ally jungle"},
+		{5000, 6000, 200, "enemy jungle"},
+		{2000, 8000, 100, "ally jungle"},
+		{9000, 8001, 100, "mid"},
+		{9000, 8000, 100, "enemy jungle"},
+	}
+	for _, tc := range cases {
+		if zone := mapZone(tc.x, tc.y, tc.teamID); zone != tc.zone {
+			t.Errorf("mapZone(%d, %d, %d) = %q, want %q", tc.x, tc.y, tc.teamID, zone, tc.zone)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package stats
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/WhiteAcres/leaguestats/client"
+	"github.com/WhiteAcres/leaguestats/storage"
+)
+
+// LiveEnemy - an enemy in the summoner's game in progress, with what the stored
+// history says about them
+type LiveEnemy struct {
+	Name       string
+	ChampionID int64
+	Champion   string
+	// Games and WinRate are the enemy's record on the champion they are playing
+	Games   int64
+	WinRate float64
+	// Pool is the enemy's most played champions, most games first
+	Pool ChampionStatsList
+	// VsSummoner is how the champion has fared against the summoner, or nil if the
+	// summoner has never played against it
+	VsSummoner *BanRecommendation
+}
+
+// LiveEnemies - the enemies in a game in progress
+type LiveEnemies []LiveEnemy
+
+// Columns returns the table header of the enemies
+func (les LiveEnemies) Columns() []string {
+	return []string{"Enemy", "Champion", "Games", "Win Rate", "Champion Pool", "Vs You"}
+}
+
+// Rows returns the table cells of the enemies
+func (les LiveEnemies) Rows() [][]string {
+	var rows [][]string
+	for _, le := range les {
+		winRate := "-"
+		if le.Games > 0 {
+			winRate = fmt.Sprintf("%.3f", le.WinRate)
+		}
+		var pool []string
+		for _, cs := range le.Pool {
+			pool = append(pool, fmt.Sprintf("%s %d (%.0f%%)", cs.Name, cs.Games, cs.WinRate*100))
+		}
+		vsSummoner := "-"
+		if le.VsSummoner != nil {
+			vs := le.VsSummoner
+			vsSummoner = fmt.Sprintf("%dW %dL (%+.3f)", vs.Victories, vs.TotalMatches-vs.Victories, vs.BanScore)
+		}
+		rows = append(rows, []string{
+			le.Name,
+			le.Champion,
+			strconv.FormatInt(le.Games, 10),
+			winRate,
+			strings.Join(pool, ", "),
+			vsSummoner,
+		})
+	}
+	return rows
+}
+
+// Records returns the enemies for JSON encoding
+func (les LiveEnemies) Records() []interface{} {
+	var records []interface{}
+	for _, le := range les {
+		records = append(records, le)
+	}
+	return records
+}
+
+// GetLiveEnemies gets the summoner's enemies in a game in progress, each with their
+// poolSize most played champions and record on the champion they are playing from
+// their stored games, and how that champion has fared against the summoner
+func GetLiveEnemies(s storage.Store, game *client.CurrentGameInfo, playerID string, poolSize int) (LiveEnemies, error) {
+	summoner, ok := game.Participant(playerID)
+	if !ok {
+		return nil, fmt.Errorf("summoner is not in game %d", game.GameID)
+	}
+	championNamesMap, err := GetChampionNames(s)
+	if err != nil {
+		return nil, err
+	}
+	filter := MatchFilter{MinDuration: RemakeDuration}
+	vsOpts := DefaultBanOptions
+	vsOpts.MinGames = 1
+	vsSummoner, err := GetBestBanForSummoner(s, playerID, vsOpts, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var enemies LiveEnemies
+	for _, p := range game.Participants {
+		if p.TeamID == summoner.TeamID {
+			continue
+		}
+		enemy := LiveEnemy{
+			Name:       p.Name(),
+			ChampionID: p.ChampionID,
+			Champion:   championName(championNamesMap, client.Participant{ChampionID: p.ChampionID}),
+		}
+		for i := range vsSummoner {
+			if vsSummoner[i].ChampionID == p.ChampionID {
+				enemy.VsSummoner = &vsSummoner[i]
+			}
+		}
+		if !p.Bot && len(p.PuuID) > 0 {
+			pool, err := GetChampionStatsForSummoner(s, p.PuuID, ChampionStatsOptions{SortBy: SortByGames}, filter)
+			if err != nil {
+				return nil, err
+			}
+			for _, cs := range pool {
+				if cs.ChampionID == p.ChampionID {
+					enemy.Games, enemy.WinRate = cs.Games, cs.WinRate
+				}
+			}
+			if poolSize > 0 && len(pool) > poolSize {
+				pool = pool[0:poolSize]
+			}
+			enemy.Pool = pool
+		}
+		enemies = append(enemies, enemy)
+	}
+	return enemies, nil
+}
+
+// LiveBans returns the best count bans of recommendations (all of them if count is 0)
+// that weren't already banned in the game
+func LiveBans(recommendations BanRecommendations, game *client.CurrentGameInfo, count int) BanRecommendations {
+	banned := make(map[int64]bool)
+	for _, b := range game.BannedChampions {
+		banned[b.ChampionID] = true
+	}
+	var bans BanRecommendations
+	for _, br := range recommendations {
+		if count > 0 && len(bans) >= count {
+			break
+		}
+		if !banned[br.ChampionID] {
+			bans = append(bans, br)
+		}
+	}
+	return bans
+}
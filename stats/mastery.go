@@ -0,0 +1,173 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/WhiteAcres/leaguestats/client"
+	"github.com/WhiteAcres/leaguestats/storage"
+)
+
+// MasteryStats - the summoner's mastery of a champion next to their record on it
+type MasteryStats struct {
+	ChampionID     int64
+	Name           string
+	ChampionLevel  int64
+	ChampionPoints int64
+	Games          int64
+	Wins           int64
+	WinRate        float64
+	// ExpectedWinRate is the win rate the summoner's other champions suggest for this
+	// many mastery points, and Residual how far WinRate is above it
+	ExpectedWinRate float64
+	Residual        float64
+}
+
+// MasteryStatsList - the summoner's champions, biggest over-performance first
+type MasteryStatsList []MasteryStats
+
+// Columns returns the table header of the champions
+func (msl MasteryStatsList) Columns() []string {
+	return []string{"Champion", "Level", "Points", "Games", "Win Rate", "Expected", "Residual"}
+}
+
+// Rows returns the table cells of the champions
+func (msl MasteryStatsList) Rows() [][]string {
+	var rows [][]string
+	for _, ms := range msl {
+		rows = append(rows, []string{
+			ms.Name,
+			strconv.FormatInt(ms.ChampionLevel, 10),
+			strconv.FormatInt(ms.ChampionPoints, 10),
+			strconv.FormatInt(ms.Games, 10),
+			fmt.Sprintf("%.3f", ms.WinRate),
+			fmt.Sprintf("%.3f", ms.ExpectedWinRate),
+			fmt.Sprintf("%+.3f", ms.Residual),
+		})
+	}
+	return rows
+}
+
+// Records returns the champions for JSON encoding
+func (msl MasteryStatsList) Records() []interface{} {
+	var records []interface{}
+	for _, ms := range msl {
+		records = append(records, ms)
+	}
+	return records
+}
+
+// MasterySnapshotsFromMasteries turns a player's Champion-Mastery-V4 masteries into
+// snapshots taken at takenAt
+func MasterySnapshotsFromMasteries(playerID string, masteries []client.ChampionMastery, takenAt time.Time) []storage.MasterySnapshot {
+	var snapshots []storage.MasterySnapshot
+	for _, m := range masteries {
+		snapshots = append(snapshots, storage.MasterySnapshot{
+			PlayerID:       playerID,
+			ChampionID:     m.ChampionID,
+			TakenAt:        takenAt,
+			ChampionLevel:  m.ChampionLevel,
+			ChampionPoints: m.ChampionPoints,
+			LastPlayed:     time.Unix(0, m.LastPlayTime*int64(time.Millisecond)),
+		})
+	}
+	return snapshots
+}
+
+// GetMasteryStatsForSummoner compares the summoner's latest recorded mastery of each
+// champion with their win rate on it in the games that satisfy the filter. Win rate is
+// fitted against the logarithm of mastery points across the champions played at least
+// minGames times, each weighted by its games, so a positive residual is a champion the
+// summoner wins on more than their experience with it suggests.
+func GetMasteryStatsForSummoner(s storage.Store, playerID string, minGames int64, filter MatchFilter) (MasteryStatsList, error) {
+	masteries, err := s.Masteries(playerID)
+	if err != nil {
+		return nil, err
+	}
+	matches, err := GetMatchesForSummoner(s, playerID, filter)
+	if err != nil {
+		return nil, err
+	}
+	championNamesMap, err := GetChampionNames(s)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make(map[int64]*MasteryStats)
+	for _, match := range matches {
+		participant, ok := getSummonerParticipant(playerID, match)
+		if !ok {
+			continue
+		}
+		ms, ok := records[participant.ChampionID]
+		if !ok {
+			ms = &MasteryStats{ChampionID: participant.ChampionID, Name: championName(championNamesMap, participant)}
+			records[participant.ChampionID] = ms
+		}
+		ms.Games++
+		if participant.Win {
+			ms.Wins++
+		}
+	}
+
+	var masteryStats MasteryStatsList
+	for _, mastery := range masteries {
+		ms, ok := records[mastery.ChampionID]
+		if !ok || ms.Games < minGames {
+			continue
+		}
+		ms.ChampionLevel = mastery.ChampionLevel
+		ms.ChampionPoints = mastery.ChampionPoints
+		ms.WinRate = float64(ms.Wins) / float64(ms.Games)
+		masteryStats = append(masteryStats, *ms)
+	}
+
+	slope, intercept := fitMastery(masteryStats)
+	for i := range masteryStats {
+		ms := &masteryStats[i]
+		ms.ExpectedWinRate = math.Max(0, math.Min(1, intercept+slope*masteryScale(ms.ChampionPoints)))
+		ms.Residual = ms.WinRate - ms.ExpectedWinRate
+	}
+	sort.SliceStable(masteryStats, func(i, j int) bool {
+		return masteryStats[i].Residual > masteryStats[j].Residual
+	})
+	return masteryStats, nil
+}
+
+// masteryScale is the mastery points win rate is fitted against; each tenfold of points
+// counts the same, as the first games on a champion teach more than the thousandth
+func masteryScale(points int64) float64 {
+	return math.Log10(float64(points) + 1)
+}
+
+// fitMastery fits win rate against masteryScale by least squares weighted by games,
+// returning a flat line at the overall win rate if the points don't vary
+func fitMastery(masteryStats MasteryStatsList) (slope, intercept float64) {
+	var games, wins, sumX, sumY float64
+	for _, ms := range masteryStats {
+		w := float64(ms.Games)
+		games += w
+		wins += float64(ms.Wins)
+		sumX += w * masteryScale(ms.ChampionPoints)
+		sumY += w * ms.WinRate
+	}
+	if games == 0 {
+		return 0, 0
+	}
+	meanX, meanY := sumX/games, sumY/games
+	var covariance, variance float64
+	for _, ms := range masteryStats {
+		w := float64(ms.Games)
+		dx := masteryScale(ms.ChampionPoints) - meanX
+		covariance += w * dx * (ms.WinRate - meanY)
+		variance += w * dx * dx
+	}
+	if variance == 0 {
+		return 0, wins / games
+	}
+	slope = covariance / variance
+	return slope, meanY - slope*meanX
+}
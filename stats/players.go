@@ -0,0 +1,31 @@
+package stats
+
+import (
+	"fmt"
+
+	"github.com/WhiteAcres/leaguestats/storage"
+)
+
+// ResolveSummoner returns the player ID of the summoner who played under name, a Riot
+// ID (Name#TAG) or a game or summoner name, in stored matches. When several players
+// have used the name, the one seen under it most recently is picked.
+func ResolveSummoner(s storage.Store, name string) (string, error) {
+	players, err := s.ResolveName(name)
+	if err != nil {
+		return "", err
+	}
+	if len(players) == 0 {
+		return "", fmt.Errorf("no stored matches for summoner %q", name)
+	}
+	return players[0].PlayerID, nil
+}
+
+// SummonerName returns the name the player was last seen under, or the player ID
+// if no name is stored for it
+func SummonerName(s storage.Store, playerID string) string {
+	names, err := s.NameHistory(playerID)
+	if err != nil || len(names) == 0 {
+		return playerID
+	}
+	return names[0].RiotID()
+}
@@ -0,0 +1,221 @@
+package stats
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/WhiteAcres/leaguestats/client"
+	"github.com/WhiteAcres/leaguestats/storage"
+)
+
+// tiers are the ranked tiers, lowest first
+var tiers = []string{"IRON", "BRONZE", "SILVER", "GOLD", "PLATINUM", "EMERALD", "DIAMOND", "MASTER", "GRANDMASTER", "CHALLENGER"}
+
+// divisions are the divisions of a tier, lowest first
+var divisions = []string{"IV", "III", "II", "I"}
+
+// apexTiers start at Master, where divisions end and LP keeps counting up
+const apexTiers = 7
+
+// rankedQueueIDs maps League-V4 queue types onto the queue IDs of their games
+var rankedQueueIDs = map[string]int64{
+	client.QueueTypeSolo: QueueRankedSolo,
+	client.QueueTypeFlex: QueueRankedFlex,
+}
+
+// RankedQueueType returns the League-V4 queue type of a ranked queue ID
+func RankedQueueType(queueID int64) (string, error) {
+	for queueType, id := range rankedQueueIDs {
+		if id == queueID {
+			return queueType, nil
+		}
+	}
+	return "", fmt.Errorf("queue %d is not a ranked queue (want solo or flex)", queueID)
+}
+
+// tierIndex returns the position of a tier in tiers, or -1 if it is unknown
+func tierIndex(tier string) int {
+	for i, t := range tiers {
+		if t == strings.ToUpper(tier) {
+			return i
+		}
+	}
+	return -1
+}
+
+// isApexTier reports whether a tier is Master or above, which have no divisions
+func isApexTier(tier string) bool {
+	return tierIndex(tier) >= apexTiers
+}
+
+// ladderPoints places a standing on a single scale, 100 points per division, so the
+// difference between two standings is the LP gained across promotions
+func ladderPoints(tier, rank string, leaguePoints int64) int64 {
+	if isApexTier(tier) {
+		// Apex tiers share one ladder, ordered by LP alone
+		return apexTiers*int64(len(divisions))*100 + leaguePoints
+	}
+	index := tierIndex(tier)
+	if index < 0 {
+		index = 0
+	}
+	division := 0
+	for i, d := range divisions {
+		if d == strings.ToUpper(rank) {
+			division = i
+		}
+	}
+	return int64(index*len(divisions)+division)*100 + leaguePoints
+}
+
+// RankedChange - the summoner's ranked standing at one fetch, with the change since the
+// previous one and the games played in between
+type RankedChange struct {
+	TakenAt      time.Time
+	QueueType    string
+	Tier         string
+	Rank         string
+	LeaguePoints int64
+	Wins         int64
+	Losses       int64
+	// Change is the LP gained since the previous standing, counting a division as 100
+	Change int64
+	// Promoted and Demoted are set when the tier or division changed
+	Promoted bool
+	Demoted  bool
+	// Matches are the stored games in the queue since the previous standing, most recent first
+	Matches MatchSummaries
+}
+
+// RankedHistory - the summoner's ranked standings in a queue, oldest first
+type RankedHistory []RankedChange
+
+// Columns returns the table header of the standings
+func (rh RankedHistory) Columns() []string {
+	return []string{"Date", "Rank", "LP", "Change", "Record", "Games"}
+}
+
+// Rows returns the table cells of the standings
+func (rh RankedHistory) Rows() [][]string {
+	var rows [][]string
+	for i, rc := range rh {
+		rank := rc.Tier
+		if !isApexTier(rc.Tier) {
+			rank += " " + rc.Rank
+		}
+		change := "-"
+		if i > 0 {
+			change = fmt.Sprintf("%+d", rc.Change)
+			if rc.Promoted {
+				change += " promoted"
+			} else if rc.Demoted {
+				change += " demoted"
+			}
+		}
+		var games []string
+		for j := len(rc.Matches) - 1; j >= 0; j-- {
+			ms := rc.Matches[j]
+			result := "L"
+			if ms.Win {
+				result = "W"
+			}
+			games = append(games, result+" "+ms.Champion)
+		}
+		rows = append(rows, []string{
+			rc.TakenAt.Format("2006-01-02 15:04"),
+			rank,
+			strconv.FormatInt(rc.LeaguePoints, 10),
+			change,
+			fmt.Sprintf("%dW %dL", rc.Wins, rc.Losses),
+			strings.Join(games, ", "),
+		})
+	}
+	return rows
+}
+
+// Records returns the standings for JSON encoding
+func (rh RankedHistory) Records() []interface{} {
+	var records []interface{}
+	for _, rc := range rh {
+		records = append(records, rc)
+	}
+	return records
+}
+
+// SnapshotsFromEntries turns a player's League-V4 entries into snapshots taken at takenAt
+func SnapshotsFromEntries(playerID string, entries []client.LeagueEntry, takenAt time.Time) []storage.LeagueSnapshot {
+	var snapshots []storage.LeagueSnapshot
+	for _, entry := range entries {
+		if _, ok := rankedQueueIDs[entry.QueueType]; !ok {
+			continue
+		}
+		snapshots = append(snapshots, storage.LeagueSnapshot{
+			PlayerID:     playerID,
+			QueueType:    entry.QueueType,
+			TakenAt:      takenAt,
+			Tier:         entry.Tier,
+			Rank:         entry.Rank,
+			LeaguePoints: entry.LeaguePoints,
+			Wins:         entry.Wins,
+			Losses:       entry.Losses,
+		})
+	}
+	return snapshots
+}
+
+// GetRankedHistoryForSummoner gets the summoner's recorded standings in a ranked queue
+// (client.QueueTypeSolo or client.QueueTypeFlex), each with the games stored since the
+// one before
+func GetRankedHistoryForSummoner(s storage.Store, playerID, queueType string) (RankedHistory, error) {
+	queueID, ok := rankedQueueIDs[queueType]
+	if !ok {
+		return nil, fmt.Errorf("unknown ranked queue type %q", queueType)
+	}
+	snapshots, err := s.LeagueSnapshots(playerID, queueType)
+	if err != nil {
+		return nil, err
+	}
+	matches, err := s.Matches(storage.MatchQuery{PlayerID: playerID, QueueIDs: []int64{queueID}})
+	if err != nil {
+		return nil, err
+	}
+	championNamesMap, err := GetChampionNames(s)
+	if err != nil {
+		return nil, err
+	}
+
+	var history RankedHistory
+	for i, snapshot := range snapshots {
+		rc := RankedChange{
+			TakenAt:      snapshot.TakenAt,
+			QueueType:    snapshot.QueueType,
+			Tier:         snapshot.Tier,
+			Rank:         snapshot.Rank,
+			LeaguePoints: snapshot.LeaguePoints,
+			Wins:         snapshot.Wins,
+			Losses:       snapshot.Losses,
+		}
+		if i > 0 {
+			previous := snapshots[i-1]
+			rc.Change = ladderPoints(snapshot.Tier, snapshot.Rank, snapshot.LeaguePoints) -
+				ladderPoints(previous.Tier, previous.Rank, previous.LeaguePoints)
+			if snapshot.Tier != previous.Tier || snapshot.Rank != previous.Rank {
+				rc.Promoted = rc.Change > 0
+				rc.Demoted = rc.Change < 0
+			}
+			for _, match := range matches {
+				played := time.Unix(0, match.Info.GameCreation*int64(time.Millisecond))
+				if !played.After(previous.TakenAt) || !played.Before(snapshot.TakenAt) {
+					continue
+				}
+				if participant, ok := getSummonerParticipant(playerID, match); ok {
+					rc.Matches = append(rc.Matches, matchSummary(match, participant, championNamesMap))
+				}
+			}
+		}
+		history = append(history, rc)
+	}
+	return history, nil
+}
@@ -0,0 +1,243 @@
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/WhiteAcres/leaguestats/client"
+	"github.com/WhiteAcres/leaguestats/storage"
+)
+
+// firstTimePoints is the mastery below which a champion with a single stored game is
+// taken to be new to the player, about a handful of games' worth
+const firstTimePoints = 5000
+
+// ScoutSummary - an opponent's overall tendencies over their stored games
+type ScoutSummary struct {
+	Summoner string
+	Games    int64
+	Wins     int64
+	WinRate  float64
+	// AvgKills, AvgDeaths, AvgAssists and the rates after them are averaged over every game
+	AvgKills     float64
+	AvgDeaths    float64
+	AvgAssists   float64
+	KDA          float64
+	CSPerMin     float64
+	GoldPerMin   float64
+	DamageShare  float64
+	VisionPerMin float64
+	// MainRole is the position played most, one of client.Positions() or "" if none
+	// was, and MainRoleShare the share of games it was played in
+	MainRole      string
+	MainRoleShare float64
+	// Streak is the current run of wins, or of losses if negative
+	Streak int64
+	// LongestWinStreak and LongestLossStreak are the longest runs in the stored games
+	LongestWinStreak  int64
+	LongestLossStreak int64
+}
+
+// Columns returns the table header of the summary
+func (ss *ScoutSummary) Columns() []string {
+	return []string{"Summoner", "Games", "Win Rate", "Main Role", "Streak", "Best/Worst Streak", "K/D/A", "KDA", "CS/min", "Gold/min", "Dmg Share", "Vision/min"}
+}
+
+// Rows returns the table cells of the summary
+func (ss *ScoutSummary) Rows() [][]string {
+	mainRole := "-"
+	if len(ss.MainRole) > 0 {
+		mainRole = fmt.Sprintf("%s (%.0f%%)", ss.MainRole, ss.MainRoleShare*100)
+	}
+	streak := "-"
+	if ss.Streak > 0 {
+		streak = fmt.Sprintf("%dW", ss.Streak)
+	} else if ss.Streak < 0 {
+		streak = fmt.Sprintf("%dL", -ss.Streak)
+	}
+	return [][]string{{
+		ss.Summoner,
+		strconv.FormatInt(ss.Games, 10),
+		fmt.Sprintf("%.3f", ss.WinRate),
+		mainRole,
+		streak,
+		fmt.Sprintf("%dW/%dL", ss.LongestWinStreak, ss.LongestLossStreak),
+		fmt.Sprintf("%.1f/%.1f/%.1f", ss.AvgKills, ss.AvgDeaths, ss.AvgAssists),
+		fmt.Sprintf("%.2f", ss.KDA),
+		fmt.Sprintf("%.1f", ss.CSPerMin),
+		fmt.Sprintf("%.0f", ss.GoldPerMin),
+		fmt.Sprintf("%.1f%%", ss.DamageShare*100),
+		fmt.Sprintf("%.2f", ss.VisionPerMin),
+	}}
+}
+
+// Records returns the summary for JSON encoding
+func (ss *ScoutSummary) Records() []interface{} {
+	return []interface{}{ss}
+}
+
+// ScoutChampion - an opponent's record on one champion
+type ScoutChampion struct {
+	ChampionStats
+	LastPlayed time.Time
+	// MasteryPoints is the opponent's latest recorded mastery of the champion, if any
+	MasteryPoints int64
+	// FirstTime is set when the opponent has barely played the champion: a single stored
+	// game and, if their mastery is known, little of it
+	FirstTime bool
+}
+
+// ScoutChampions - an opponent's champions, most played first
+type ScoutChampions []ScoutChampion
+
+// Columns returns the table header of the champions
+func (scs ScoutChampions) Columns() []string {
+	return []string{"Champion", "Games", "Win Rate", "K/D/A", "KDA", "CS/min", "Mastery", "Last Played", "Flags"}
+}
+
+// Rows returns the table cells of the champions
+func (scs ScoutChampions) Rows() [][]string {
+	var rows [][]string
+	for _, sc := range scs {
+		mastery := "-"
+		if sc.MasteryPoints > 0 {
+			mastery = strconv.FormatInt(sc.MasteryPoints, 10)
+		}
+		flags := ""
+		if sc.FirstTime {
+			flags = "first time"
+		}
+		rows = append(rows, []string{
+			sc.Name,
+			strconv.FormatInt(sc.Games, 10),
+			fmt.Sprintf("%.3f", sc.WinRate),
+			fmt.Sprintf("%.1f/%.1f/%.1f", sc.AvgKills, sc.AvgDeaths, sc.AvgAssists),
+			fmt.Sprintf("%.2f", sc.KDA),
+			fmt.Sprintf("%.1f", sc.CSPerMin),
+			mastery,
+			sc.LastPlayed.Format("2006-01-02"),
+			flags,
+		})
+	}
+	return rows
+}
+
+// Records returns the champions for JSON encoding
+func (scs ScoutChampions) Records() []interface{} {
+	var records []interface{}
+	for _, sc := range scs {
+		records = append(records, sc)
+	}
+	return records
+}
+
+// ScoutSummoner sizes up an opponent from their stored games that satisfy the filter:
+// their overall tendencies, and their champions, most played first
+func ScoutSummoner(s storage.Store, playerID string, filter MatchFilter) (*ScoutSummary, ScoutChampions, error) {
+	matches, err := GetMatchesForSummoner(s, playerID, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	championNamesMap, err := GetChampionNames(s)
+	if err != nil {
+		return nil, nil, err
+	}
+	masteries, err := s.Masteries(playerID)
+	if err != nil {
+		return nil, nil, err
+	}
+	masteryPoints := make(map[int64]int64)
+	for _, mastery := range masteries {
+		masteryPoints[mastery.ChampionID] = mastery.ChampionPoints
+	}
+
+	summary := &ScoutSummary{Summoner: SummonerName(s, playerID)}
+	var overall championTotals
+	roles := make(map[string]int64)
+	totalsByChampion := make(map[int64]*championTotals)
+	lastPlayed := make(map[int64]int64)
+	names := make(map[int64]string)
+	var run int64
+	// Matches come most recent first
+	for _, match := range matches {
+		participant, ok := getSummonerParticipant(playerID, match)
+		if !ok {
+			continue
+		}
+		overall.add(participant, match)
+		if position := participant.Position(); len(position) > 0 {
+			roles[position]++
+		}
+
+		t, ok := totalsByChampion[participant.ChampionID]
+		if !ok {
+			t = &championTotals{}
+			totalsByChampion[participant.ChampionID] = t
+			names[participant.ChampionID] = championName(championNamesMap, participant)
+			lastPlayed[participant.ChampionID] = match.Info.GameCreation
+		}
+		t.add(participant, match)
+
+		// run is the streak the game is part of, counting back from the most recent game
+		if run != 0 && participant.Win == (run > 0) {
+			if run > 0 {
+				run++
+			} else {
+				run--
+			}
+		} else if participant.Win {
+			run = 1
+		} else {
+			run = -1
+		}
+		// The first run, unbroken since the most recent game, is the current streak
+		if abs(run) == overall.games {
+			summary.Streak = run
+		}
+		if run > summary.LongestWinStreak {
+			summary.LongestWinStreak = run
+		}
+		if -run > summary.LongestLossStreak {
+			summary.LongestLossStreak = -run
+		}
+	}
+	if overall.games == 0 {
+		return summary, nil, nil
+	}
+	averages := overall.stats(0, "")
+	summary.Games, summary.Wins, summary.WinRate = averages.Games, averages.Wins, averages.WinRate
+	summary.AvgKills, summary.AvgDeaths, summary.AvgAssists = averages.AvgKills, averages.AvgDeaths, averages.AvgAssists
+	summary.KDA = averages.KDA
+	summary.CSPerMin = averages.CSPerMin
+	summary.GoldPerMin = averages.GoldPerMin
+	summary.DamageShare = averages.DamageShare
+	summary.VisionPerMin = averages.VisionPerMin
+	for _, position := range client.Positions() {
+		if roles[position] > roles[summary.MainRole] {
+			summary.MainRole = position
+		}
+	}
+	if len(summary.MainRole) > 0 {
+		summary.MainRoleShare = float64(roles[summary.MainRole]) / float64(overall.games)
+	}
+
+	var champions ScoutChampions
+	for championID, t := range totalsByChampion {
+		points, hasMastery := masteryPoints[championID]
+		champions = append(champions, ScoutChampion{
+			ChampionStats: t.stats(championID, names[championID]),
+			LastPlayed:    time.Unix(0, lastPlayed[championID]*int64(time.Millisecond)),
+			MasteryPoints: points,
+			FirstTime:     t.games == 1 && (!hasMastery || points < firstTimePoints),
+		})
+	}
+	sort.SliceStable(champions, func(i, j int) bool {
+		if champions[i].Games != champions[j].Games {
+			return champions[i].Games > champions[j].Games
+		}
+		return champions[i].LastPlayed.After(champions[j].LastPlayed)
+	})
+	return summary, champions, nil
+}
@@ -1,125 +1,154 @@
 package stats
 
 import (
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"math"
-	"net/http"
 	"sort"
 	"strconv"
-	"strings"
+	"time"
 
 	"github.com/WhiteAcres/leaguestats/client"
+	"github.com/WhiteAcres/leaguestats/ddragon"
+	"github.com/WhiteAcres/leaguestats/patch"
 	"github.com/WhiteAcres/leaguestats/storage"
 )
 
-type teamChampionPair struct {
-	TeamID     int64
-	ChampionID int64
-}
-
-type enemyChampionIDStatsObject struct {
+// BanRecommendation - how an enemy champion fared against the summoner.
+// Victories and WinRate are the enemy champion's, i.e. the summoner's defeats.
+type BanRecommendation struct {
+	ChampionID   int64
 	Name         string
 	TotalMatches int64
 	Victories    int64
 	WinRate      float64
-	BanScore     float64
+	// WinRateLower and WinRateUpper bound the enemy champion's true win rate
+	// (Wilson score interval at BanOptions.Z, over the recency-weighted games)
+	WinRateLower float64
+	WinRateUpper float64
+	// LaneMatches is how many of TotalMatches the champion was the summoner's lane opponent in
+	LaneMatches int64
+	// Baseline is the enemy win rate over all the summoner's games, i.e. their loss rate
+	Baseline float64
+	// BanScore is how far WinRateLower is above Baseline: positive when the champion
+	// beats the summoner more often than usual even on a pessimistic estimate
+	BanScore float64
 }
 
-type ddragonChampionPageObject struct {
-	Kind    string `json:"type"`
-	Format  string
-	Version string
-	Data    map[string]ddragonChampionObject
+// BanOptions - tuning of GetBestBanForSummoner
+type BanOptions struct {
+	// MinGames leaves out champions seen in fewer games
+	MinGames int64
+	// HalfLife is how much older a game is than the most recent one when it counts
+	// half as much; every game counts the same if zero
+	HalfLife time.Duration
+	// Z is the standard score of the confidence interval, e.g. 1.96 for 95%
+	Z float64
+	// LaneWeight blends lane-opponent and whole-team results: the summoner's lane
+	// opponent always counts fully and the other enemies count 1-LaneWeight, so 0
+	// weighs every enemy the same and 1 only looks at lane opponents
+	LaneWeight float64
 }
 
-type ddragonChampionObject struct {
-	ID   string
-	Key  string
-	Name string
+// DefaultBanOptions are the options the CLI uses unless told otherwise
+var DefaultBanOptions = BanOptions{
+	MinGames:   3,
+	HalfLife:   30 * 24 * time.Hour,
+	Z:          1.96,
+	LaneWeight: 0.5,
 }
 
-func summonerInMatch(summonerName string, match client.Match) bool {
-	participantsIdentities := match.ParticipantIdentities
-	for _, participant := range participantsIdentities {
-		player := participant.Player
-		if player.SummonerName == summonerName {
-			return true
-		}
-	}
-	return false
+// BanRecommendations - ban recommendations, best ban first
+type BanRecommendations []BanRecommendation
+
+// Columns returns the table header of the recommendations
+func (brs BanRecommendations) Columns() []string {
+	return []string{"Champion", "Times Seen", "In Lane", "Defeats", "Enemy Win Rate", "Interval", "Baseline", "Ban Score"}
 }
 
-func getParticipantIDForSummonerInMatch(summonerName string, match client.Match) int64 {
-	participantsIdentities := match.ParticipantIdentities
-	for _, participant := range participantsIdentities {
-		player := participant.Player
-		if player.SummonerName == summonerName {
-			return participant.ParticipantID
-		}
+// Rows returns the table cells of the recommendations
+func (brs BanRecommendations) Rows() [][]string {
+	var rows [][]string
+	for _, br := range brs {
+		rows = append(rows, []string{
+			br.Name,
+			strconv.FormatInt(br.TotalMatches, 10),
+			strconv.FormatInt(br.LaneMatches, 10),
+			strconv.FormatInt(br.Victories, 10),
+			fmt.Sprintf("%.3f", br.WinRate),
+			fmt.Sprintf("%.3f-%.3f", br.WinRateLower, br.WinRateUpper),
+			fmt.Sprintf("%.3f", br.Baseline),
+			fmt.Sprintf("%+.3f", br.BanScore),
+		})
 	}
-	return -1
+	return rows
 }
 
-// returns true if the first gameVersion string is greater than the second, false otherwise
-func versionCompare(gv1, gv2 string) bool {
-	gv1Sections := strings.Split(gv1, ".")
-	gv1S0, _ := strconv.ParseInt(gv1Sections[0], 10, 32)
-	gv1S1, _ := strconv.ParseInt(gv1Sections[1], 10, 32)
-	gv1S2, _ := strconv.ParseInt(gv1Sections[2], 10, 32)
-	gv1S3, _ := strconv.ParseInt(gv1Sections[3], 10, 32)
-	gv2Sections := strings.Split(gv2, ".")
-	gv2S0, _ := strconv.ParseInt(gv2Sections[0], 10, 32)
-	gv2S1, _ := strconv.ParseInt(gv2Sections[1], 10, 32)
-	gv2S2, _ := strconv.ParseInt(gv2Sections[2], 10, 32)
-	gv2S3, _ := strconv.ParseInt(gv2Sections[3], 10, 32)
-	if gv1S0 > gv2S0 && gv1S1 > gv2S1 && gv1S2 > gv2S2 && gv1S3 > gv2S3 {
-		return true
+// Records returns the recommendations for JSON encoding
+func (brs BanRecommendations) Records() []interface{} {
+	var records []interface{}
+	for _, br := range brs {
+		records = append(records, br)
 	}
-	return false
+	return records
+}
+
+// isSummoner checks if a participant is the player, by the ID of Participant.PlayerID
+func isSummoner(playerID string, participant client.Participant) bool {
+	return len(playerID) > 0 && participant.PlayerID() == playerID
 }
 
-// GetLatestGameVersion returns the latest gameVersion from all matches in storage
-func GetLatestGameVersion(s storage.Storage) string {
-	data := s.Data
-	var gameVersion string = "0.0.0.0"
-	for _, match := range data {
-		if versionCompare(match.GameVersion, gameVersion) {
-			gameVersion = match.GameVersion
+// GetLatestGameVersion returns the latest gameVersion from all matches in storage,
+// or "" if no match has a valid one
+func GetLatestGameVersion(s storage.Store) (string, error) {
+	versions, err := s.GameVersions()
+	if err != nil {
+		return "", err
+	}
+	var gameVersion string
+	var latest patch.Patch
+	for _, version := range versions {
+		p, err := patch.Parse(version)
+		if err != nil {
+			continue
+		}
+		if len(gameVersion) == 0 || latest.Less(p) {
+			gameVersion = version
+			latest = p
 		}
 	}
-	return gameVersion
+	return gameVersion, nil
 }
 
 // GetMatches gets all the matches in storage
-func GetMatches(s storage.Storage) []client.Match {
-	var matches []client.Match
-	data := s.Data
-	for _, match := range data {
-		matches = append(matches, match)
-	}
-	return matches
+func GetMatches(s storage.Store) ([]client.Match, error) {
+	return s.Matches(storage.MatchQuery{})
 }
 
-// GetMatchesForSummoner gets all the matches for a summoner
-func GetMatchesForSummoner(s storage.Storage, summonerName string) []client.Match {
-	var summonerMatches []client.Match
-	data := s.Data
-	for _, match := range data {
-		if summonerInMatch(summonerName, match) {
-			summonerMatches = append(summonerMatches, match)
+// GetMatchesForSummoner gets the matches of a summoner that satisfy the filter
+func GetMatchesForSummoner(s storage.Store, playerID string, filter MatchFilter) ([]client.Match, error) {
+	matches, err := s.Matches(filter.query(playerID))
+	if err != nil {
+		return nil, err
+	}
+	var filtered []client.Match
+	for _, match := range matches {
+		if filter.Match(playerID, match) {
+			filtered = append(filtered, match)
 		}
 	}
-	return summonerMatches
+	return filtered, nil
 }
 
-// isSR checks if a match is SR or not
+// isSR checks if a match is a game against players on Summoner's Rift
 func isSR(match client.Match) bool {
-	if match.QueueID != 840 && match.GameMode == "CLASSIC" {
-		return true
+	if botQueues[match.Info.QueueID] {
+		return false
 	}
-	return false
+	if match.Info.MapID != 0 {
+		return match.Info.MapID == summonersRiftMapID
+	}
+	// Matches converted from Match-V4 may lack the map
+	return match.Info.GameMode == "CLASSIC"
 }
 
 // GetSRMatches filters the matches list to be only SR matches
@@ -133,198 +162,152 @@ func GetSRMatches(matches []client.Match) []client.Match {
 	return SRMatches
 }
 
-// GetVictoryMatchesForSummoner gets all the victory matches for a summoner
-func GetVictoryMatchesForSummoner(s storage.Storage, summonerName string) []client.Match {
-	var summonerVictoryMatches []client.Match
-	data := s.Data
-	for _, match := range data {
-		if summonerInMatch(summonerName, match) {
-			summonerPID := getParticipantIDForSummonerInMatch(summonerName, match)
-			victory := false
-			for _, participant := range match.Participants {
-				if participant.ParticipantID == summonerPID {
-					victory = participant.Stats.Win
-					break
-				}
-			}
-			if victory {
-				summonerVictoryMatches = append(summonerVictoryMatches, match)
-			}
-		}
+// wilsonInterval returns the Wilson score interval of a proportion of wins out of
+// games at standard score z. Games may be fractional when they are weighted.
+func wilsonInterval(wins, games, z float64) (lower, upper float64) {
+	if games <= 0 {
+		return 0, 1
 	}
-	return summonerVictoryMatches
+	p := wins / games
+	z2 := z * z
+	center := (p + z2/(2*games)) / (1 + z2/games)
+	margin := z / (1 + z2/games) * math.Sqrt(p*(1-p)/games+z2/(4*games*games))
+	return math.Max(0, center-margin), math.Min(1, center+margin)
 }
 
-// GetDefeatMatchesForSummoner gets all the defeat matches for a summoner
-func GetDefeatMatchesForSummoner(s storage.Storage, summonerName string) []client.Match {
-	var summonerDefeatMatches []client.Match
-	data := s.Data
-	for _, match := range data {
-		if summonerInMatch(summonerName, match) {
-			summonerPID := getParticipantIDForSummonerInMatch(summonerName, match)
-			victory := false
-			for _, participant := range match.Participants {
-				if participant.ParticipantID == summonerPID {
-					victory = participant.Stats.Win
-					break
-				}
-			}
-			if victory == false {
-				summonerDefeatMatches = append(summonerDefeatMatches, match)
-			}
-		}
+// recencyWeight returns how much a game played at creation counts next to the most
+// recent game, halving every halfLife
+func recencyWeight(creation, mostRecent int64, halfLife time.Duration) float64 {
+	if halfLife <= 0 {
+		return 1
 	}
-	return summonerDefeatMatches
+	age := time.Duration(mostRecent-creation) * time.Millisecond
+	return math.Pow(0.5, float64(age)/float64(halfLife))
 }
 
-// GetEnemyChampionCountsInMatches returns count of all champions that summoner played against
-func GetEnemyChampionCountsInMatches(summonerName string, matches []client.Match) map[int64]int64 {
-	enemyChampCounts := make(map[int64]int64)
-	for _, match := range matches {
-		participantTeamMap := make(map[int64]*teamChampionPair)
-		for _, participant := range match.Participants {
-			participantTeamMap[participant.ParticipantID] = &teamChampionPair{participant.TeamID, participant.ChampionID}
-		}
-		summonerPID := getParticipantIDForSummonerInMatch(summonerName, match)
-		summonerTID := participantTeamMap[summonerPID].TeamID
-		for _, pair := range participantTeamMap {
-			if pair.TeamID != summonerTID {
-				champID := pair.ChampionID
-				if val, ok := enemyChampCounts[champID]; ok {
-					enemyChampCounts[champID] = val + 1
-				} else {
-					enemyChampCounts[champID] = 1
-				}
-			}
-		}
-	}
-	return enemyChampCounts
-}
-
-// GetChampionCountsInMatches returns count of each champion from match list
-func GetChampionCountsInMatches(matches []client.Match) map[int64]int64 {
-	champCounts := make(map[int64]int64)
-	for _, match := range matches {
-		for _, participant := range match.Participants {
-			champID := participant.ChampionID
-			if val, ok := champCounts[champID]; ok {
-				champCounts[champID] = val + 1
-			} else {
-				champCounts[champID] = 1
-			}
-		}
-	}
-	return champCounts
+// enemyChampionRecord - the games the summoner played against one champion
+type enemyChampionRecord struct {
+	games, victories, laneGames      int64
+	weightedGames, weightedVictories float64
 }
 
-func getChampionWinRates(championCounts map[int64]int64, championVictoryCounts map[int64]int64) map[int64]float64 {
-	championWinRates := make(map[int64]float64)
-	for champID, totalCount := range championCounts {
-		wins := float64(0)
-		if val, ok := championVictoryCounts[champID]; ok {
-			wins += float64(val)
-		}
-		winrate, _ := strconv.ParseFloat(fmt.Sprintf("%.3f", float64(wins)/float64(totalCount)), 64)
-		championWinRates[champID] = winrate
-	}
-	return championWinRates
-}
-
-func calculateChampionBanScores(enemyChampCounts map[int64]int64, enemyChampWinRates map[int64]float64) map[int64]float64 {
-	championBanScores := make(map[int64]float64)
-	for champID, rate := range enemyChampWinRates {
-		count := int64(1)
-		if val, ok := enemyChampCounts[champID]; ok {
-			count = val
-		}
-		adjRate := float64(rate) + float64(.5)
-		score, _ := strconv.ParseFloat(fmt.Sprintf("%.3f", math.Pow(adjRate, float64(count))), 64)
-		championBanScores[champID] = score
-	}
-	return championBanScores
-}
-
-func getChampionNamesMap(latestGameVersion string) (map[int64]string, error) {
-	// Creating the requess
-	championNamesMap := make(map[int64]string)
-	lgvSections := strings.Split(latestGameVersion, ".")
-	lgvS0 := lgvSections[0]
-	lgvS1 := lgvSections[1]
-	urlstring := "http://ddragon.leagueoflegends.com/cdn/" + lgvS0 + "." + lgvS1 + ".1" + "/data/en_US/champion.json"
-	req, err := http.NewRequest("GET", urlstring, nil)
+// GetChampionNames returns the champion names for the latest game version in storage,
+// from the Data Dragon cache. Names are left out if that patch was never downloaded
+// and Data Dragon can't be reached.
+func GetChampionNames(s storage.Store) (map[int64]string, error) {
+	latestGameVersion, err := GetLatestGameVersion(s)
 	if err != nil {
 		return nil, err
 	}
-
-	client := http.Client{}
-	resp, err := client.Do(req)
+	dd := ddragon.Default()
+	version, err := dd.VersionFor(latestGameVersion)
 	if err != nil {
-		return nil, err
+		return make(map[int64]string), nil
 	}
+	champions, err := dd.Champions(version)
+	if err != nil {
+		return make(map[int64]string), nil
+	}
+	return champions.Names(), nil
+}
 
-	// Translating response
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
+// GetBestBanForSummoner gets the ban recommendations for the summoner, best ban
+// first, from their Summoner's Rift games that satisfy the filter. Each enemy
+// champion's win rate is estimated pessimistically, by the lower bound of its
+// confidence interval, and compared with the summoner's usual loss rate so a single
+// lost game can't outrank a champion that reliably beats them.
+func GetBestBanForSummoner(s storage.Store, playerID string, opts BanOptions, filter MatchFilter) (BanRecommendations, error) {
+	summonerMatches, err := GetMatchesForSummoner(s, playerID, filter)
 	if err != nil {
 		return nil, err
 	}
-	var ddcpo ddragonChampionPageObject
-	err = json.Unmarshal(body, &ddcpo)
+	championNamesMap, err := GetChampionNames(s)
 	if err != nil {
 		return nil, err
 	}
-	for _, v := range ddcpo.Data {
-		champID, _ := strconv.ParseInt(v.Key, 10, 64)
-		championNamesMap[champID] = v.Name
-	}
-	return championNamesMap, nil
+	return banRecommendations(playerID, GetSRMatches(summonerMatches), championNamesMap, opts, false), nil
 }
 
-// GetBestBanForSummoner gets the best ban for the summoner
-func GetBestBanForSummoner(s storage.Storage, summonerName string) {
-	summonerMatches := GetMatchesForSummoner(s, summonerName)
-	summonerSRMatches := GetSRMatches(summonerMatches)
-	enemyChampCounts := GetEnemyChampionCountsInMatches(summonerName, summonerSRMatches)
-
-	summonerDefeatMatches := GetDefeatMatchesForSummoner(s, summonerName)
-	summonerDefeatSRMatches := GetSRMatches(summonerDefeatMatches)
-	enemyChampVictoryCounts := GetEnemyChampionCountsInMatches(summonerName, summonerDefeatSRMatches)
-
-	enemyChampWinRates := getChampionWinRates(enemyChampCounts, enemyChampVictoryCounts)
-	enemyChampionBanScores := calculateChampionBanScores(enemyChampCounts, enemyChampWinRates)
+// banRecommendations scores the enemy champions of the summoner's matches, or only
+// their lane opponents if laneOnly is set
+func banRecommendations(playerID string, matches []client.Match, championNamesMap map[int64]string, opts BanOptions, laneOnly bool) BanRecommendations {
+	var mostRecent int64
+	for _, match := range matches {
+		if match.Info.GameCreation > mostRecent {
+			mostRecent = match.Info.GameCreation
+		}
+	}
 
-	latestGameVersion := GetLatestGameVersion(s)
-	championNamesMap, _ := getChampionNamesMap(latestGameVersion)
+	var weightedGames, weightedDefeats float64
+	records := make(map[int64]*enemyChampionRecord)
+	for _, match := range matches {
+		summoner, ok := getSummonerParticipant(playerID, match)
+		if !ok {
+			continue
+		}
+		weight := recencyWeight(match.Info.GameCreation, mostRecent, opts.HalfLife)
+		weightedGames += weight
+		if !summoner.Win {
+			weightedDefeats += weight
+		}
+		opponent, hasOpponent := match.LaneOpponent(summoner)
+		for _, participant := range match.Info.Participants {
+			isOpponent := hasOpponent && participant.ParticipantID == opponent.ParticipantID
+			if participant.TeamID == summoner.TeamID || (laneOnly && !isOpponent) {
+				continue
+			}
+			record, ok := records[participant.ChampionID]
+			if !ok {
+				record = &enemyChampionRecord{}
+				records[participant.ChampionID] = record
+			}
+			champWeight := weight * (1 - opts.LaneWeight)
+			if isOpponent {
+				champWeight = weight
+				record.laneGames++
+			}
+			record.games++
+			record.weightedGames += champWeight
+			if !summoner.Win {
+				record.victories++
+				record.weightedVictories += champWeight
+			}
+		}
+	}
+	var baseline float64
+	if weightedGames > 0 {
+		baseline = weightedDefeats / weightedGames
+	}
 
-	var enemyChampionIDStatsList []enemyChampionIDStatsObject
-	for champID, count := range enemyChampCounts {
+	var recommendations BanRecommendations
+	for champID, record := range records {
+		// Champions never met in lane carry no weight when only lane opponents count
+		if record.games < opts.MinGames || record.weightedGames == 0 {
+			continue
+		}
 		champName := "None"
 		if val, ok := championNamesMap[champID]; ok {
 			champName = val
 		}
-		victories := int64(0)
-		if val, ok := enemyChampVictoryCounts[champID]; ok {
-			victories = val
-		}
-		winrate := float64(0)
-		if val, ok := enemyChampWinRates[champID]; ok {
-			winrate = val
-		}
-		banscore := float64(0)
-		if val, ok := enemyChampionBanScores[champID]; ok {
-			banscore = val
-		}
-		eciso := enemyChampionIDStatsObject{champName, count, victories, winrate, banscore}
-		enemyChampionIDStatsList = append(enemyChampionIDStatsList, eciso)
+		lower, upper := wilsonInterval(record.weightedVictories, record.weightedGames, opts.Z)
+		recommendations = append(recommendations, BanRecommendation{
+			ChampionID:   champID,
+			Name:         champName,
+			TotalMatches: record.games,
+			Victories:    record.victories,
+			WinRate:      float64(record.victories) / float64(record.games),
+			WinRateLower: lower,
+			WinRateUpper: upper,
+			LaneMatches:  record.laneGames,
+			Baseline:     baseline,
+			BanScore:     lower - baseline,
+		})
 	}
-	sort.Slice(enemyChampionIDStatsList, func(i, j int) bool {
-		return enemyChampionIDStatsList[i].BanScore > enemyChampionIDStatsList[j].BanScore
+	sort.Slice(recommendations, func(i, j int) bool {
+		if recommendations[i].BanScore != recommendations[j].BanScore {
+			return recommendations[i].BanScore > recommendations[j].BanScore
+		}
+		return recommendations[i].Name < recommendations[j].Name
 	})
-	for _, eciso := range enemyChampionIDStatsList {
-		TotalMatchesString := strconv.FormatInt(eciso.TotalMatches, 10)
-		DefeatsString := strconv.FormatInt(eciso.Victories, 10)
-		WinRateString := fmt.Sprintf("%.3f", eciso.WinRate)
-		BanScoreString := fmt.Sprintf("%.3f", eciso.BanScore)
-		fmt.Println(eciso.Name + " - " + "Times Seen: " + TotalMatchesString + " Defeats: " + DefeatsString + " Enemy Win Rate: " + WinRateString + " Ban Score: " + BanScoreString)
-	}
+	return recommendations
 }
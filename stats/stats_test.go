@@ -0,0 +1,258 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/WhiteAcres/leaguestats/client"
+	"github.com/WhiteAcres/leaguestats/ddragon"
+	"github.com/WhiteAcres/leaguestats/storage"
+)
+
+// openTestStore opens an empty store holding matches
+func openTestStore(t *testing.T, matches ...*client.Match) storage.Store {
+	store, err := storage.OpenSQLite(filepath.Join(t.TempDir(), "matches.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	if err = store.UpsertMatches(matches); err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+// testMatch returns a Summoner's Rift match on the game version
+func testMatch(matchID, gameVersion string, gameCreation int64, participants ...client.Participant) *client.Match {
+	return &client.Match{
+		Metadata: client.MatchMetadata{MatchID: matchID},
+		Info: client.MatchInfo{
+			GameCreation: gameCreation,
+			GameDuration: 1800,
+			GameVersion:  gameVersion,
+			MapID:        summonersRiftMapID,
+			QueueID:      QueueRankedSolo,
+			Participants: participants,
+		},
+	}
+}
+
+func TestGetLatestGameVersion(t *testing.T) {
+	store := openTestStore(t)
+	if version, err := GetLatestGameVersion(store); err != nil || version != "" {
+		t.Errorf("GetLatestGameVersion() of an empty store = %q, %v, want \"\"", version, err)
+	}
+
+	store = openTestStore(t,
+		testMatch("NA1_1", "14.9.580.1", 3),
+		// Newer although it sorts first as text
+		testMatch("NA1_2", "14.10.585.2", 1),
+		testMatch("NA1_3", "14.10.585.1", 2),
+		testMatch("NA1_4", "lolpatch_3.7", 4),
+	)
+	if version, err := GetLatestGameVersion(store); err != nil || version != "14.10.585.2" {
+		t.Errorf("GetLatestGameVersion() = %q, %v, want 14.10.585.2", version, err)
+	}
+}
+
+func TestScoutSummoner(t *testing.T) {
+	// Champion names come from the participants, without downloading Data Dragon
+	offline := ddragon.New(t.TempDir())
+	offline.Offline = true
+	saved := ddragon.Default()
+	ddragon.SetDefault(offline)
+	defer ddragon.SetDefault(saved)
+
+	played := func(championID int64, champion string, win bool) client.Participant {
+		return client.Participant{PuuID: "puuid-a", ChampionID: championID, ChampionName: champion,
+			TeamPosition: "MIDDLE", Win: win, Kills: 4, Deaths: 2, Assists: 6}
+	}
+	store := openTestStore(t,
+		testMatch("NA1_1", "14.3.1", 1, played(103, "Ahri", false)),
+		testMatch("NA1_2", "14.3.1", 2, played(103, "Ahri", true)),
+		testMatch("NA1_3", "14.3.1", 3, played(7, "LeBlanc", true)),
+	)
+	summary, champions, err := ScoutSummoner(store, "puuid-a", MatchFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Games != 3 || summary.Wins != 2 || summary.Streak != 2 || summary.MainRole != "MIDDLE" {
+		t.Errorf("summary = %+v", summary)
+	}
+	if summary.KDA != 5 {
+		t.Errorf("summary KDA = %v, want 5", summary.KDA)
+	}
+	if len(champions) != 2 || champions[0].Name != "Ahri" || champions[0].Games != 2 || !champions[1].FirstTime {
+		t.Errorf("champions = %+v", champions)
+	}
+
+	// The summary is of every champion, so has no champion of its own to encode
+	b, err := json.Marshal(summary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]interface{}
+	if err = json.Unmarshal(b, &fields); err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{"ChampionID", "Name"} {
+		if _, ok := fields[field]; ok {
+			t.Errorf("summary JSON has %s: %s", field, b)
+		}
+	}
+}
+
+func TestWilsonInterval(t *testing.T) {
+	cases := []struct {
+		wins, games, z float64
+		lower, upper   float64
+	}{
+		{5, 10, 1.96, 0.2366, 0.7634},
+		{0, 10, 1.96, 0, 0.2775},
+		{10, 10, 1.96, 0.7225, 1},
+		// A single game says little either way
+		{1, 1, 1.96, 0.2065, 1},
+		{30, 40, 1.96, 0.5981, 0.8581},
+		// Weighted games are fractional
+		{2.5, 5, 1, 0.2959, 0.7041},
+		{0, 0, 1.96, 0, 1},
+		{0, -1, 1.96, 0, 1},
+	}
+	for _, tc := range cases {
+		lower, upper := wilsonInterval(tc.wins, tc.games, tc.z)
+		if math.Abs(lower-tc.lower) > 1e-4 || math.Abs(upper-tc.upper) > 1e-4 {
+			t.Errorf("wilsonInterval(%v, %v, %v) = %.4f, %.4f, want %.4f, %.4f", tc.wins, tc.games, tc.z, lower, upper, tc.lower, tc.upper)
+		}
+	}
+}
+
+func TestRecencyWeight(t *testing.T) {
+	halfLife := 30 * 24 * time.Hour
+	mostRecent := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC).UnixNano() / int64(time.Millisecond)
+	cases := []struct {
+		age    time.Duration
+		weight float64
+	}{
+		{0, 1},
+		{halfLife, 0.5},
+		{2 * halfLife, 0.25},
+		{halfLife / 2, math.Sqrt(0.5)},
+	}
+	for _, tc := range cases {
+		creation := mostRecent - int64(tc.age/time.Millisecond)
+		if w := recencyWeight(creation, mostRecent, halfLife); math.Abs(w-tc.weight) > 1e-9 {
+			t.Errorf("recencyWeight of a game %s old = %v, want %v", tc.age, w, tc.weight)
+		}
+		// Without a half-life every game counts the same
+		if w := recencyWeight(creation, mostRecent, 0); w != 1 {
+			t.Errorf("recencyWeight of a game %s old without a half-life = %v, want 1", tc.age, w)
+		}
+	}
+}
+
+// laneMatches returns games of the summoner "me" in the middle lane against the champion
+// in the enemy middle lane, with Jinx in the enemy bottom lane, winning the first wins
+func laneMatches(championID int64, games, wins int) []client.Match {
+	var matches []client.Match
+	for i := 0; i < games; i++ {
+		matches = append(matches, *testMatch(fmt.Sprintf("NA1_%d_%d", championID, i), "14.3.1", int64(i),
+			client.Participant{ParticipantID: 1, PuuID: "me", TeamID: 100, TeamPosition: "MIDDLE", ChampionID: 1, Win: i < wins},
+			client.Participant{ParticipantID: 6, PuuID: "mid", TeamID: 200, TeamPosition: "MIDDLE", ChampionID: championID, Win: i >= wins},
+			client.Participant{ParticipantID: 9, PuuID: "adc", TeamID: 200, TeamPosition: "BOTTOM", ChampionID: 222, Win: i >= wins},
+		))
+	}
+	return matches
+}
+
+func TestBanRecommendations(t *testing.T) {
+	var matches []client.Match
+	// Zed won the one game he was seen in
+	matches = append(matches, laneMatches(238, 1, 0)...)
+	// Ahri won 10 of 12, reliably above the summoner's usual loss rate
+	matches = append(matches, laneMatches(103, 12, 2)...)
+	// Lux never won
+	matches = append(matches, laneMatches(99, 20, 20)...)
+	names := map[int64]string{238: "Zed", 103: "Ahri", 99: "Lux", 222: "Jinx"}
+	// 11 defeats in 33 games
+	baseline := 11.0 / 33
+
+	opts := BanOptions{MinGames: 1, Z: 1.96, LaneWeight: 0.5}
+	recommendations := banRecommendations("me", matches, names, opts, false)
+	var order []string
+	for _, br := range recommendations {
+		order = append(order, br.Name)
+		if math.Abs(br.Baseline-baseline) > 1e-9 {
+			t.Errorf("%s baseline = %v, want %v", br.Name, br.Baseline, baseline)
+		}
+	}
+	if got := fmt.Sprint(order); got != "[Ahri Zed Jinx Lux]" {
+		t.Errorf("recommendations = %s, want Ahri, who reliably wins, above Zed's single game", got)
+	}
+	if ahri := recommendations[0]; ahri.TotalMatches != 12 || ahri.Victories != 10 || ahri.LaneMatches != 12 || ahri.BanScore <= 0 {
+		t.Errorf("Ahri = %+v", ahri)
+	}
+
+	// MinGames leaves out champions seen too rarely
+	opts.MinGames = 3
+	for _, br := range banRecommendations("me", matches, names, opts, false) {
+		if br.Name == "Zed" {
+			t.Errorf("Zed, seen once, recommended with MinGames 3: %+v", br)
+		}
+	}
+
+	// Jinx is never the lane opponent, so counts fully at LaneWeight 0 and not at all at 1
+	jinx := func(laneWeight float64) (BanRecommendation, bool) {
+		opts := BanOptions{MinGames: 1, Z: 1.96, LaneWeight: laneWeight}
+		for _, br := range banRecommendations("me", matches, names, opts, false) {
+			if br.Name == "Jinx" {
+				return br, true
+			}
+		}
+		return BanRecommendation{}, false
+	}
+	br, ok := jinx(0)
+	if lower, _ := wilsonInterval(11, 33, 1.96); !ok || br.TotalMatches != 33 || math.Abs(br.WinRateLower-lower) > 1e-9 {
+		t.Errorf("Jinx at LaneWeight 0 = %+v, want every game counted fully", br)
+	}
+	br, ok = jinx(0.5)
+	if lower, _ := wilsonInterval(5.5, 16.5, 1.96); !ok || math.Abs(br.WinRateLower-lower) > 1e-9 {
+		t.Errorf("Jinx at LaneWeight 0.5 = %+v, want every game counted half", br)
+	}
+	if br, ok = jinx(1); ok {
+		t.Errorf("Jinx recommended at LaneWeight 1: %+v", br)
+	}
+
+	// laneOnly leaves out everyone but the lane opponent
+	for _, br := range banRecommendations("me", matches, names, BanOptions{MinGames: 1, Z: 1.96}, true) {
+		if br.Name == "Jinx" || br.LaneMatches != br.TotalMatches {
+			t.Errorf("lane-only recommendation %+v", br)
+		}
+	}
+}
+
+func TestBanRecommendationsHalfLife(t *testing.T) {
+	day := int64(24 * time.Hour / time.Millisecond)
+	// Zed won a game 30 days before losing the most recent one
+	matches := laneMatches(238, 2, 1)
+	matches[0].Info.GameCreation = 0
+	matches[1].Info.GameCreation = 30 * day
+	matches[0].Info.Participants[0].Win, matches[1].Info.Participants[0].Win = false, true
+
+	opts := BanOptions{MinGames: 1, Z: 1.96, HalfLife: 30 * 24 * time.Hour, LaneWeight: 1}
+	recommendations := banRecommendations("me", matches, nil, opts, false)
+	if len(recommendations) != 1 {
+		t.Fatalf("recommendations = %+v", recommendations)
+	}
+	// The older victory counts half
+	zed := recommendations[0]
+	if lower, upper := wilsonInterval(0.5, 1.5, 1.96); math.Abs(zed.WinRateLower-lower) > 1e-9 || math.Abs(zed.WinRateUpper-upper) > 1e-9 {
+		t.Errorf("Zed interval = %v-%v, want %v-%v", zed.WinRateLower, zed.WinRateUpper, lower, upper)
+	}
+	if math.Abs(zed.Baseline-0.5/1.5) > 1e-9 || zed.WinRate != 0.5 {
+		t.Errorf("Zed = %+v, want a weighted baseline of 1/3 and an unweighted win rate of 1/2", zed)
+	}
+}
@@ -0,0 +1,437 @@
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/WhiteAcres/leaguestats/client"
+	"github.com/WhiteAcres/leaguestats/storage"
+)
+
+// Summary - overall record of a summoner
+type Summary struct {
+	Summoner   string
+	Games      int64
+	Wins       int64
+	Losses     int64
+	WinRate    float64
+	AvgKills   float64
+	AvgDeaths  float64
+	AvgAssists float64
+	KDA        float64
+}
+
+// ChampionStats - record and performance of a summoner on one champion
+type ChampionStats struct {
+	ChampionID int64
+	Name       string
+	Games      int64
+	Wins       int64
+	WinRate    float64
+	AvgKills   float64
+	AvgDeaths  float64
+	AvgAssists float64
+	KDA        float64
+	// CSPerMin counts lane minions and jungle monsters
+	CSPerMin   float64
+	GoldPerMin float64
+	// DamageShare is the average share of the team's damage to champions the summoner dealt
+	DamageShare  float64
+	VisionPerMin float64
+	AvgDuration  time.Duration
+}
+
+// ChampionSort - an order for the champion report
+type ChampionSort string
+
+// Champion report orders, each best or largest first except by name
+const (
+	SortByGames    ChampionSort = "games"
+	SortByWinRate  ChampionSort = "winrate"
+	SortByKDA      ChampionSort = "kda"
+	SortByCS       ChampionSort = "cs"
+	SortByGold     ChampionSort = "gold"
+	SortByDamage   ChampionSort = "damage"
+	SortByVision   ChampionSort = "vision"
+	SortByDuration ChampionSort = "duration"
+	SortByName     ChampionSort = "name"
+)
+
+// ChampionSorts returns every champion report order
+func ChampionSorts() []ChampionSort {
+	return []ChampionSort{SortByGames, SortByWinRate, SortByKDA, SortByCS, SortByGold, SortByDamage, SortByVision, SortByDuration, SortByName}
+}
+
+// ParseChampionSort parses a champion report order (e.g. from --sort)
+func ParseChampionSort(name string) (ChampionSort, error) {
+	var names []string
+	for _, cs := range ChampionSorts() {
+		if string(cs) == strings.ToLower(name) {
+			return cs, nil
+		}
+		names = append(names, string(cs))
+	}
+	return "", fmt.Errorf("unknown sort %q (want one of %s)", name, strings.Join(names, ", "))
+}
+
+// ChampionStatsOptions - orders the champion report
+type ChampionStatsOptions struct {
+	// SortBy orders the champions; SortByGames if empty
+	SortBy ChampionSort
+}
+
+// MatchSummary - a summoner's line in one match
+type MatchSummary struct {
+	MatchID  string
+	Played   time.Time
+	QueueID  int64
+	Champion string
+	Kills    int64
+	Deaths   int64
+	Assists  int64
+	Win      bool
+	Duration time.Duration
+}
+
+// Columns returns the table header of the summary
+func (sm *Summary) Columns() []string {
+	return []string{"Summoner", "Games", "Wins", "Losses", "Win Rate", "K/D/A", "KDA"}
+}
+
+// Rows returns the table cells of the summary
+func (sm *Summary) Rows() [][]string {
+	return [][]string{{
+		sm.Summoner,
+		strconv.FormatInt(sm.Games, 10),
+		strconv.FormatInt(sm.Wins, 10),
+		strconv.FormatInt(sm.Losses, 10),
+		fmt.Sprintf("%.3f", sm.WinRate),
+		fmt.Sprintf("%.1f/%.1f/%.1f", sm.AvgKills, sm.AvgDeaths, sm.AvgAssists),
+		fmt.Sprintf("%.2f", sm.KDA),
+	}}
+}
+
+// Records returns the summary for JSON encoding
+func (sm *Summary) Records() []interface{} {
+	return []interface{}{sm}
+}
+
+// ChampionStatsList - a summoner's records on each champion
+type ChampionStatsList []ChampionStats
+
+// Columns returns the table header of the champion records
+func (csl ChampionStatsList) Columns() []string {
+	return []string{"Champion", "Games", "Wins", "Win Rate", "K/D/A", "KDA", "CS/min", "Gold/min", "Dmg Share", "Vision/min", "Avg Duration"}
+}
+
+// Rows returns the table cells of the champion records
+func (csl ChampionStatsList) Rows() [][]string {
+	var rows [][]string
+	for _, cs := range csl {
+		rows = append(rows, []string{
+			cs.Name,
+			strconv.FormatInt(cs.Games, 10),
+			strconv.FormatInt(cs.Wins, 10),
+			fmt.Sprintf("%.3f", cs.WinRate),
+			fmt.Sprintf("%.1f/%.1f/%.1f", cs.AvgKills, cs.AvgDeaths, cs.AvgAssists),
+			fmt.Sprintf("%.2f", cs.KDA),
+			fmt.Sprintf("%.1f", cs.CSPerMin),
+			fmt.Sprintf("%.0f", cs.GoldPerMin),
+			fmt.Sprintf("%.1f%%", cs.DamageShare*100),
+			fmt.Sprintf("%.2f", cs.VisionPerMin),
+			cs.AvgDuration.String(),
+		})
+	}
+	return rows
+}
+
+// Records returns the champion records for JSON encoding
+func (csl ChampionStatsList) Records() []interface{} {
+	var records []interface{}
+	for _, cs := range csl {
+		records = append(records, cs)
+	}
+	return records
+}
+
+// MatchSummaries - a summoner's lines in several matches, most recent first
+type MatchSummaries []MatchSummary
+
+// Columns returns the table header of the match lines
+func (mss MatchSummaries) Columns() []string {
+	return []string{"Match", "Played", "Queue", "Champion", "K/D/A", "Result", "Duration"}
+}
+
+// Rows returns the table cells of the match lines
+func (mss MatchSummaries) Rows() [][]string {
+	var rows [][]string
+	for _, ms := range mss {
+		result := "Defeat"
+		if ms.Win {
+			result = "Victory"
+		}
+		rows = append(rows, []string{
+			ms.MatchID,
+			ms.Played.Format("2006-01-02 15:04"),
+			strconv.FormatInt(ms.QueueID, 10),
+			ms.Champion,
+			fmt.Sprintf("%d/%d/%d", ms.Kills, ms.Deaths, ms.Assists),
+			result,
+			ms.Duration.String(),
+		})
+	}
+	return rows
+}
+
+// Records returns the match lines for JSON encoding
+func (mss MatchSummaries) Records() []interface{} {
+	var records []interface{}
+	for _, ms := range mss {
+		records = append(records, ms)
+	}
+	return records
+}
+
+// getSummonerParticipant returns the summoner's participant in a match
+func getSummonerParticipant(playerID string, match client.Match) (client.Participant, bool) {
+	for _, participant := range match.Info.Participants {
+		if isSummoner(playerID, participant) {
+			return participant, true
+		}
+	}
+	return client.Participant{}, false
+}
+
+// kda returns (kills + assists) / deaths, treating a deathless record as one death
+func kda(kills, deaths, assists float64) float64 {
+	if deaths == 0 {
+		deaths = 1
+	}
+	return (kills + assists) / deaths
+}
+
+// GetSummaryForSummoner gets the overall record of the summoner in the games that satisfy the filter
+func GetSummaryForSummoner(s storage.Store, playerID string, filter MatchFilter) (*Summary, error) {
+	matches, err := GetMatchesForSummoner(s, playerID, filter)
+	if err != nil {
+		return nil, err
+	}
+	summary := &Summary{Summoner: SummonerName(s, playerID)}
+	var kills, deaths, assists int64
+	for _, match := range matches {
+		participant, ok := getSummonerParticipant(playerID, match)
+		if !ok {
+			continue
+		}
+		summary.Games++
+		if participant.Win {
+			summary.Wins++
+		}
+		kills += participant.Kills
+		deaths += participant.Deaths
+		assists += participant.Assists
+	}
+	summary.Losses = summary.Games - summary.Wins
+	if summary.Games > 0 {
+		games := float64(summary.Games)
+		summary.WinRate = float64(summary.Wins) / games
+		summary.AvgKills = float64(kills) / games
+		summary.AvgDeaths = float64(deaths) / games
+		summary.AvgAssists = float64(assists) / games
+		summary.KDA = kda(float64(kills), float64(deaths), float64(assists))
+	}
+	return summary, nil
+}
+
+// championTotals - sums over a summoner's games, on one champion or overall
+type championTotals struct {
+	games, wins, kills, deaths, assists, cs, gold, vision int64
+	damageShare                                           float64
+	seconds                                               int64
+}
+
+// add adds the participant's game to the totals
+func (t *championTotals) add(participant client.Participant, match client.Match) {
+	t.games++
+	if participant.Win {
+		t.wins++
+	}
+	t.kills += participant.Kills
+	t.deaths += participant.Deaths
+	t.assists += participant.Assists
+	t.cs += participant.TotalMinionsKilled + participant.NeutralMinionsKilled
+	t.gold += participant.GoldEarned
+	t.vision += participant.VisionScore
+	t.damageShare += teamDamageShare(participant, match)
+	t.seconds += match.GameDurationSeconds()
+}
+
+// stats averages the totals over their games, which must be at least one
+func (t *championTotals) stats(championID int64, name string) ChampionStats {
+	games := float64(t.games)
+	return ChampionStats{
+		ChampionID:   championID,
+		Name:         name,
+		Games:        t.games,
+		Wins:         t.wins,
+		WinRate:      float64(t.wins) / games,
+		AvgKills:     float64(t.kills) / games,
+		AvgDeaths:    float64(t.deaths) / games,
+		AvgAssists:   float64(t.assists) / games,
+		KDA:          kda(float64(t.kills), float64(t.deaths), float64(t.assists)),
+		CSPerMin:     perMinute(t.cs, t.seconds),
+		GoldPerMin:   perMinute(t.gold, t.seconds),
+		DamageShare:  t.damageShare / games,
+		VisionPerMin: perMinute(t.vision, t.seconds),
+		AvgDuration:  time.Duration(t.seconds/t.games) * time.Second,
+	}
+}
+
+// teamDamageShare returns the share of the team's damage to champions the participant dealt
+func teamDamageShare(participant client.Participant, match client.Match) float64 {
+	var teamDamage int64
+	for _, p := range match.Info.Participants {
+		if p.TeamID == participant.TeamID {
+			teamDamage += p.TotalDamageDealtToChampions
+		}
+	}
+	if teamDamage == 0 {
+		return 0
+	}
+	return float64(participant.TotalDamageDealtToChampions) / float64(teamDamage)
+}
+
+// perMinute returns total per minute of seconds played
+func perMinute(total, seconds int64) float64 {
+	if seconds == 0 {
+		return 0
+	}
+	return float64(total) / (float64(seconds) / 60)
+}
+
+// GetChampionStatsForSummoner gets the summoner's record and performance on each
+// champion they played in the games that satisfy the filter, in the order asked for
+// (most played first by default)
+func GetChampionStatsForSummoner(s storage.Store, playerID string, opts ChampionStatsOptions, filter MatchFilter) (ChampionStatsList, error) {
+	matches, err := GetMatchesForSummoner(s, playerID, filter)
+	if err != nil {
+		return nil, err
+	}
+	championNamesMap, err := GetChampionNames(s)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[int64]string)
+	totalsByChampion := make(map[int64]*championTotals)
+	for _, match := range matches {
+		participant, ok := getSummonerParticipant(playerID, match)
+		if !ok {
+			continue
+		}
+		t, ok := totalsByChampion[participant.ChampionID]
+		if !ok {
+			t = &championTotals{}
+			totalsByChampion[participant.ChampionID] = t
+			names[participant.ChampionID] = championName(championNamesMap, participant)
+		}
+		t.add(participant, match)
+	}
+
+	var championStatsList ChampionStatsList
+	for championID, t := range totalsByChampion {
+		championStatsList = append(championStatsList, t.stats(championID, names[championID]))
+	}
+	sortChampionStats(championStatsList, opts.SortBy)
+	return championStatsList, nil
+}
+
+// sortChampionStats orders the champion records by the given column, largest first
+// (by name alphabetically), falling back to most played and then name
+func sortChampionStats(csl ChampionStatsList, sortBy ChampionSort) {
+	key := func(cs ChampionStats) float64 {
+		switch sortBy {
+		case SortByWinRate:
+			return cs.WinRate
+		case SortByKDA:
+			return cs.KDA
+		case SortByCS:
+			return cs.CSPerMin
+		case SortByGold:
+			return cs.GoldPerMin
+		case SortByDamage:
+			return cs.DamageShare
+		case SortByVision:
+			return cs.VisionPerMin
+		case SortByDuration:
+			return float64(cs.AvgDuration)
+		case SortByName:
+			return 0
+		}
+		return float64(cs.Games)
+	}
+	sort.Slice(csl, func(i, j int) bool {
+		if ki, kj := key(csl[i]), key(csl[j]); ki != kj {
+			return ki > kj
+		}
+		if sortBy != SortByName && csl[i].Games != csl[j].Games {
+			return csl[i].Games > csl[j].Games
+		}
+		return csl[i].Name < csl[j].Name
+	})
+}
+
+// GetMatchSummariesForSummoner gets the summoner's most recent matches that satisfy
+// the filter, at most count (all of them if count is 0)
+func GetMatchSummariesForSummoner(s storage.Store, playerID string, count int, filter MatchFilter) (MatchSummaries, error) {
+	matches, err := GetMatchesForSummoner(s, playerID, filter)
+	if err != nil {
+		return nil, err
+	}
+	championNamesMap, err := GetChampionNames(s)
+	if err != nil {
+		return nil, err
+	}
+
+	var matchSummaries MatchSummaries
+	for _, match := range matches {
+		if count > 0 && len(matchSummaries) >= count {
+			break
+		}
+		participant, ok := getSummonerParticipant(playerID, match)
+		if !ok {
+			continue
+		}
+		matchSummaries = append(matchSummaries, matchSummary(match, participant, championNamesMap))
+	}
+	return matchSummaries, nil
+}
+
+// matchSummary returns the participant's line in a match
+func matchSummary(match client.Match, participant client.Participant, championNamesMap map[int64]string) MatchSummary {
+	return MatchSummary{
+		MatchID:  match.Metadata.MatchID,
+		Played:   time.Unix(0, match.Info.GameCreation*int64(time.Millisecond)),
+		QueueID:  match.Info.QueueID,
+		Champion: championName(championNamesMap, participant),
+		Kills:    participant.Kills,
+		Deaths:   participant.Deaths,
+		Assists:  participant.Assists,
+		Win:      participant.Win,
+		Duration: time.Duration(match.GameDurationSeconds()) * time.Second,
+	}
+}
+
+// championName returns the display name of a participant's champion
+func championName(championNamesMap map[int64]string, participant client.Participant) string {
+	if val, ok := championNamesMap[participant.ChampionID]; ok {
+		return val
+	}
+	if len(participant.ChampionName) > 0 {
+		return participant.ChampionName
+	}
+	return "None"
+}
@@ -0,0 +1,855 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/WhiteAcres/leaguestats/client"
+
+	// Registers the pure-Go "sqlite" database/sql driver
+	_ "modernc.org/sqlite"
+)
+
+// migrations are applied in order, PRAGMA user_version recording how many have run
+var migrations = []string{
+	`CREATE TABLE matches (
+		match_id      TEXT PRIMARY KEY,
+		platform_id   TEXT NOT NULL,
+		game_id       INTEGER NOT NULL,
+		queue_id      INTEGER NOT NULL,
+		game_mode     TEXT NOT NULL,
+		game_type     TEXT NOT NULL,
+		map_id        INTEGER NOT NULL,
+		game_version  TEXT NOT NULL,
+		patch         TEXT NOT NULL,
+		game_creation INTEGER NOT NULL,
+		game_duration INTEGER NOT NULL,
+		data          TEXT NOT NULL
+	);
+	CREATE INDEX matches_queue ON matches (queue_id);
+	CREATE INDEX matches_patch ON matches (patch);
+	CREATE INDEX matches_creation ON matches (game_creation);
+
+	CREATE TABLE participants (
+		match_id                        TEXT NOT NULL REFERENCES matches (match_id),
+		participant_id                  INTEGER NOT NULL,
+		puuid                           TEXT NOT NULL,
+		summoner_id                     TEXT NOT NULL,
+		summoner_name                   TEXT NOT NULL,
+		riot_id_game_name               TEXT NOT NULL,
+		riot_id_tagline                 TEXT NOT NULL,
+		team_id                         INTEGER NOT NULL,
+		champion_id                     INTEGER NOT NULL,
+		team_position                   TEXT NOT NULL,
+		win                             INTEGER NOT NULL,
+		kills                           INTEGER NOT NULL,
+		deaths                          INTEGER NOT NULL,
+		assists                         INTEGER NOT NULL,
+		total_minions_killed            INTEGER NOT NULL,
+		neutral_minions_killed          INTEGER NOT NULL,
+		gold_earned                     INTEGER NOT NULL,
+		total_damage_dealt_to_champions INTEGER NOT NULL,
+		vision_score                    INTEGER NOT NULL,
+		PRIMARY KEY (match_id, participant_id)
+	);
+	CREATE INDEX participants_puuid ON participants (puuid);
+	CREATE INDEX participants_summoner_name ON participants (summoner_name);
+	CREATE INDEX participants_riot_id ON participants (riot_id_game_name);
+	CREATE INDEX participants_champion ON participants (champion_id);
+
+	CREATE TABLE teams (
+		match_id     TEXT NOT NULL REFERENCES matches (match_id),
+		team_id      INTEGER NOT NULL,
+		win          INTEGER NOT NULL,
+		first_blood  INTEGER NOT NULL,
+		first_tower  INTEGER NOT NULL,
+		first_dragon INTEGER NOT NULL,
+		first_baron  INTEGER NOT NULL,
+		tower_kills  INTEGER NOT NULL,
+		dragon_kills INTEGER NOT NULL,
+		baron_kills  INTEGER NOT NULL,
+		PRIMARY KEY (match_id, team_id)
+	);
+
+	CREATE TABLE bans (
+		match_id    TEXT NOT NULL REFERENCES matches (match_id),
+		team_id     INTEGER NOT NULL,
+		pick_turn   INTEGER NOT NULL,
+		champion_id INTEGER NOT NULL,
+		PRIMARY KEY (match_id, team_id, pick_turn)
+	);
+	CREATE INDEX bans_champion ON bans (champion_id);`,
+
+	// Players are identified by PUUID (or Match-V4 account ID) rather than by name.
+	// A NULL player_id marks rows stored before, which backfillPlayerIDs fills in.
+	`ALTER TABLE participants ADD COLUMN player_id TEXT;
+	CREATE INDEX participants_player ON participants (player_id);
+
+	CREATE TABLE summoner_names (
+		player_id     TEXT NOT NULL,
+		game_name     TEXT NOT NULL,
+		tag_line      TEXT NOT NULL,
+		summoner_name TEXT NOT NULL,
+		first_seen    INTEGER NOT NULL,
+		last_seen     INTEGER NOT NULL,
+		PRIMARY KEY (player_id, game_name, tag_line, summoner_name)
+	);
+	CREATE INDEX summoner_names_game_name ON summoner_names (game_name COLLATE NOCASE);
+	CREATE INDEX summoner_names_summoner_name ON summoner_names (summoner_name COLLATE NOCASE);`,
+
+	// Timelines are fetched separately from their match, so they don't reference it
+	// and survive the match being stored again
+	`CREATE TABLE timelines (
+		match_id       TEXT PRIMARY KEY,
+		frame_interval INTEGER NOT NULL,
+		data           TEXT NOT NULL
+	);
+
+	CREATE TABLE timeline_frames (
+		match_id              TEXT NOT NULL REFERENCES timelines (match_id),
+		timestamp             INTEGER NOT NULL,
+		participant_id        INTEGER NOT NULL,
+		total_gold            INTEGER NOT NULL,
+		current_gold          INTEGER NOT NULL,
+		level                 INTEGER NOT NULL,
+		xp                    INTEGER NOT NULL,
+		minions_killed        INTEGER NOT NULL,
+		jungle_minions_killed INTEGER NOT NULL,
+		x                     INTEGER NOT NULL,
+		y                     INTEGER NOT NULL,
+		PRIMARY KEY (match_id, timestamp, participant_id)
+	);
+
+	CREATE TABLE timeline_events (
+		match_id       TEXT NOT NULL REFERENCES timelines (match_id),
+		event_index    INTEGER NOT NULL,
+		timestamp      INTEGER NOT NULL,
+		type           TEXT NOT NULL,
+		participant_id INTEGER NOT NULL,
+		killer_id      INTEGER NOT NULL,
+		victim_id      INTEGER NOT NULL,
+		team_id        INTEGER NOT NULL,
+		x              INTEGER,
+		y              INTEGER,
+		PRIMARY KEY (match_id, event_index)
+	);
+	CREATE INDEX timeline_events_type ON timeline_events (type);`,
+
+	// A player's ranked standing, recorded whenever it changed since the last fetch
+	`CREATE TABLE league_snapshots (
+		player_id     TEXT NOT NULL,
+		queue_type    TEXT NOT NULL,
+		taken_at      INTEGER NOT NULL,
+		tier          TEXT NOT NULL,
+		rank          TEXT NOT NULL,
+		league_points INTEGER NOT NULL,
+		wins          INTEGER NOT NULL,
+		losses        INTEGER NOT NULL,
+		PRIMARY KEY (player_id, queue_type, taken_at)
+	);`,
+
+	// A player's champion mastery, recorded whenever it changed since the last fetch
+	`CREATE TABLE mastery_snapshots (
+		player_id       TEXT NOT NULL,
+		champion_id     INTEGER NOT NULL,
+		taken_at        INTEGER NOT NULL,
+		champion_level  INTEGER NOT NULL,
+		champion_points INTEGER NOT NULL,
+		last_play_time  INTEGER NOT NULL,
+		PRIMARY KEY (player_id, champion_id, taken_at)
+	);`,
+
+	// The platform a player was last looked up on, as matches only record where each game was played
+	`CREATE TABLE player_platforms (
+		player_id    TEXT PRIMARY KEY,
+		platform     TEXT NOT NULL,
+		looked_up_at INTEGER NOT NULL
+	);`,
+
+	// Other IDs a player is known by, such as the account ID of Match-V4 games, which
+	// predate PUUIDs
+	`CREATE TABLE player_aliases (
+		alias     TEXT PRIMARY KEY,
+		player_id TEXT NOT NULL
+	);`,
+}
+
+// matchChildTables hold rows keyed by match_id that go away with their match
+var matchChildTables = []string{"bans", "teams", "participants"}
+
+// timelineTables hold a match's timeline, children first
+var timelineTables = []string{"timeline_events", "timeline_frames", "timelines"}
+
+// SQLiteStore - Store backed by an embedded SQLite database
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// OpenSQLite opens (creating if necessary) the SQLite match store at path
+func OpenSQLite(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)")
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer, so share one connection rather than contend for the lock
+	db.SetMaxOpenConns(1)
+
+	s := &SQLiteStore{db}
+	if err = s.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err = s.backfillPlayerIDs(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// migrate brings the schema up to date
+func (s *SQLiteStore) migrate() error {
+	var version int
+	if err := s.db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+	for ; version < len(migrations); version++ {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err = tx.Exec(migrations[version]); err != nil {
+			tx.Rollback()
+			return err
+		}
+		// PRAGMA does not accept bound parameters
+		if _, err = tx.Exec("PRAGMA user_version = " + strconv.Itoa(version+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err = tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillPlayerIDs stores again the matches saved before players were identified by
+// ID, filling in their player IDs and name history from the match data
+func (s *SQLiteStore) backfillPlayerIDs() error {
+	matches, err := s.queryMatches(`SELECT m.data FROM matches m WHERE EXISTS
+		(SELECT 1 FROM participants p WHERE p.match_id = m.match_id AND p.player_id IS NULL)`)
+	if err != nil || len(matches) == 0 {
+		return err
+	}
+	pointers := make([]*client.Match, len(matches))
+	for i := range matches {
+		pointers[i] = &matches[i]
+	}
+	return s.UpsertMatches(pointers)
+}
+
+// Close closes the database
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// UpsertMatches inserts matches into the store or replaces them if they already exist
+func (s *SQLiteStore) UpsertMatches(matches []*client.Match) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	for _, match := range matches {
+		if err = deleteMatch(tx, match.Metadata.MatchID); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err = insertMatch(tx, match); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// DeleteMatches deletes matches and their timelines from the store
+func (s *SQLiteStore) DeleteMatches(matchIDs []string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	for _, matchID := range matchIDs {
+		if err = deleteTimeline(tx, matchID); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err = deleteMatch(tx, matchID); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func deleteMatch(tx *sql.Tx, matchID string) error {
+	for _, table := range matchChildTables {
+		if _, err := tx.Exec("DELETE FROM "+table+" WHERE match_id = ?", matchID); err != nil {
+			return err
+		}
+	}
+	_, err := tx.Exec("DELETE FROM matches WHERE match_id = ?", matchID)
+	return err
+}
+
+func insertMatch(tx *sql.Tx, match *client.Match) error {
+	match, err := resolveAliases(tx, match)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(match)
+	if err != nil {
+		return err
+	}
+	info := match.Info
+	_, err = tx.Exec(`INSERT INTO matches (match_id, platform_id, game_id, queue_id, game_mode,
+		game_type, map_id, game_version, patch, game_creation, game_duration, data)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		match.Metadata.MatchID, info.PlatformID, info.GameID, info.QueueID, info.GameMode,
+		info.GameType, info.MapID, info.GameVersion, gamePatch(info.GameVersion), info.GameCreation,
+		match.GameDurationSeconds(), string(data))
+	if err != nil {
+		return err
+	}
+
+	for _, p := range info.Participants {
+		_, err = tx.Exec(`INSERT INTO participants (match_id, participant_id, puuid, summoner_id,
+			summoner_name, riot_id_game_name, riot_id_tagline, team_id, champion_id, team_position,
+			win, kills, deaths, assists, total_minions_killed, neutral_minions_killed, gold_earned,
+			total_damage_dealt_to_champions, vision_score, player_id)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			match.Metadata.MatchID, p.ParticipantID, p.PuuID, p.SummonerID,
+			p.SummonerName, p.RiotIDGameName, p.RiotIDTagline, p.TeamID, p.ChampionID, p.TeamPosition,
+			p.Win, p.Kills, p.Deaths, p.Assists, p.TotalMinionsKilled, p.NeutralMinionsKilled, p.GoldEarned,
+			p.TotalDamageDealtToChampions, p.VisionScore, p.PlayerID())
+		if err != nil {
+			return err
+		}
+		if err = insertName(tx, p, info.GameCreation); err != nil {
+			return err
+		}
+	}
+
+	for _, t := range info.Teams {
+		o := t.Objectives
+		_, err = tx.Exec(`INSERT INTO teams (match_id, team_id, win, first_blood, first_tower,
+			first_dragon, first_baron, tower_kills, dragon_kills, baron_kills)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			match.Metadata.MatchID, t.TeamID, t.Win, o.Champion.First, o.Tower.First,
+			o.Dragon.First, o.Baron.First, o.Tower.Kills, o.Dragon.Kills, o.Baron.Kills)
+		if err != nil {
+			return err
+		}
+		for _, b := range t.Bans {
+			_, err = tx.Exec(`INSERT OR REPLACE INTO bans (match_id, team_id, pick_turn, champion_id)
+				VALUES (?, ?, ?, ?)`,
+				match.Metadata.MatchID, t.TeamID, b.PickTurn, b.ChampionID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// resolveAliases returns the match with the PUUID filled in for participants known by
+// an alias of a player, so they are stored under the player's ID
+func resolveAliases(tx *sql.Tx, match *client.Match) (*client.Match, error) {
+	resolved := *match
+	resolved.Info.Participants = append([]client.Participant(nil), match.Info.Participants...)
+	for i := range resolved.Info.Participants {
+		p := &resolved.Info.Participants[i]
+		if len(p.PlayerID()) == 0 || p.PlayerID() == p.PuuID {
+			continue
+		}
+		var playerID string
+		err := tx.QueryRow("SELECT player_id FROM player_aliases WHERE alias = ?", p.PlayerID()).Scan(&playerID)
+		if err == sql.ErrNoRows {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		p.PuuID = playerID
+	}
+	return &resolved, nil
+}
+
+// insertName records the names a participant played under in the name history.
+// History is kept when matches are pruned, so old names still resolve.
+func insertName(tx *sql.Tx, p client.Participant, gameCreation int64) error {
+	playerID := p.PlayerID()
+	if len(playerID) == 0 || (len(p.RiotIDGameName) == 0 && len(p.SummonerName) == 0) {
+		return nil
+	}
+	_, err := tx.Exec(`INSERT INTO summoner_names (player_id, game_name, tag_line, summoner_name,
+		first_seen, last_seen) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (player_id, game_name, tag_line, summoner_name) DO UPDATE SET
+		first_seen = MIN(first_seen, excluded.first_seen), last_seen = MAX(last_seen, excluded.last_seen)`,
+		playerID, p.RiotIDGameName, p.RiotIDTagline, p.SummonerName, gameCreation, gameCreation)
+	return err
+}
+
+// UpsertTimelines inserts match timelines into the store or replaces them if they already exist
+func (s *SQLiteStore) UpsertTimelines(timelines []*client.Timeline) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	for _, timeline := range timelines {
+		if err = deleteTimeline(tx, timeline.Metadata.MatchID); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err = insertTimeline(tx, timeline); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func deleteTimeline(tx *sql.Tx, matchID string) error {
+	for _, table := range timelineTables {
+		if _, err := tx.Exec("DELETE FROM "+table+" WHERE match_id = ?", matchID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func insertTimeline(tx *sql.Tx, timeline *client.Timeline) error {
+	data, err := json.Marshal(timeline)
+	if err != nil {
+		return err
+	}
+	matchID := timeline.Metadata.MatchID
+	_, err = tx.Exec("INSERT INTO timelines (match_id, frame_interval, data) VALUES (?, ?, ?)",
+		matchID, timeline.Info.FrameInterval, string(data))
+	if err != nil {
+		return err
+	}
+
+	eventIndex := 0
+	for _, frame := range timeline.Info.Frames {
+		for _, pf := range frame.ParticipantFrames {
+			_, err = tx.Exec(`INSERT OR REPLACE INTO timeline_frames (match_id, timestamp, participant_id,
+				total_gold, current_gold, level, xp, minions_killed, jungle_minions_killed, x, y)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				matchID, frame.Timestamp, pf.ParticipantID,
+				pf.TotalGold, pf.CurrentGold, pf.Level, pf.XP, pf.MinionsKilled, pf.JungleMinionsKilled,
+				pf.Position.X, pf.Position.Y)
+			if err != nil {
+				return err
+			}
+		}
+		for _, event := range frame.Events {
+			var x, y interface{}
+			if event.Position != nil {
+				x, y = event.Position.X, event.Position.Y
+			}
+			_, err = tx.Exec(`INSERT INTO timeline_events (match_id, event_index, timestamp, type,
+				participant_id, killer_id, victim_id, team_id, x, y)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				matchID, eventIndex, event.Timestamp, event.Type,
+				event.ParticipantID, event.KillerID, event.VictimID, event.TeamID, x, y)
+			if err != nil {
+				return err
+			}
+			eventIndex++
+		}
+	}
+	return nil
+}
+
+// Timelines returns the stored timelines of the matches, keyed by match ID. Matches
+// without a stored timeline are left out.
+func (s *SQLiteStore) Timelines(matchIDs []string) (map[string]*client.Timeline, error) {
+	timelines := make(map[string]*client.Timeline)
+	for _, matchID := range matchIDs {
+		var data string
+		err := s.db.QueryRow("SELECT data FROM timelines WHERE match_id = ?", matchID).Scan(&data)
+		if err == sql.ErrNoRows {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		var timeline client.Timeline
+		if err = json.Unmarshal([]byte(data), &timeline); err != nil {
+			return nil, err
+		}
+		timelines[matchID] = &timeline
+	}
+	return timelines, nil
+}
+
+// FilterTimelineIDs returns the matchIDs whose timeline is not already found in the store
+func (s *SQLiteStore) FilterTimelineIDs(matchIDs []string) ([]string, error) {
+	var filteredMatchIDs []string
+	for _, matchID := range matchIDs {
+		var found int
+		err := s.db.QueryRow("SELECT COUNT(*) FROM timelines WHERE match_id = ?", matchID).Scan(&found)
+		if err != nil {
+			return nil, err
+		}
+		if found == 0 {
+			filteredMatchIDs = append(filteredMatchIDs, matchID)
+		}
+	}
+	return filteredMatchIDs, nil
+}
+
+// AddLeagueSnapshots records ranked standings, skipping any that are the same as the
+// player's latest one in the queue. It returns how many were recorded.
+func (s *SQLiteStore) AddLeagueSnapshots(snapshots []LeagueSnapshot) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	added := 0
+	for _, ls := range snapshots {
+		var latest LeagueSnapshot
+		err = tx.QueryRow(`SELECT tier, rank, league_points, wins, losses FROM league_snapshots
+			WHERE player_id = ? AND queue_type = ? ORDER BY taken_at DESC LIMIT 1`,
+			ls.PlayerID, ls.QueueType).Scan(&latest.Tier, &latest.Rank, &latest.LeaguePoints, &latest.Wins, &latest.Losses)
+		if err == nil && latest.Tier == ls.Tier && latest.Rank == ls.Rank &&
+			latest.LeaguePoints == ls.LeaguePoints && latest.Wins == ls.Wins && latest.Losses == ls.Losses {
+			continue
+		} else if err != nil && err != sql.ErrNoRows {
+			tx.Rollback()
+			return 0, err
+		}
+		_, err = tx.Exec(`INSERT OR REPLACE INTO league_snapshots (player_id, queue_type, taken_at,
+			tier, rank, league_points, wins, losses) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			ls.PlayerID, ls.QueueType, ls.TakenAt.UnixNano()/int64(time.Millisecond),
+			ls.Tier, ls.Rank, ls.LeaguePoints, ls.Wins, ls.Losses)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		added++
+	}
+	return added, tx.Commit()
+}
+
+// LeagueSnapshots returns the player's recorded standings in the queue, oldest first
+func (s *SQLiteStore) LeagueSnapshots(playerID, queueType string) ([]LeagueSnapshot, error) {
+	rows, err := s.db.Query(`SELECT taken_at, tier, rank, league_points, wins, losses
+		FROM league_snapshots WHERE player_id = ? AND queue_type = ? ORDER BY taken_at`,
+		playerID, queueType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snapshots []LeagueSnapshot
+	for rows.Next() {
+		ls := LeagueSnapshot{PlayerID: playerID, QueueType: queueType}
+		var takenAt int64
+		if err = rows.Scan(&takenAt, &ls.Tier, &ls.Rank, &ls.LeaguePoints, &ls.Wins, &ls.Losses); err != nil {
+			return nil, err
+		}
+		ls.TakenAt = time.Unix(0, takenAt*int64(time.Millisecond))
+		snapshots = append(snapshots, ls)
+	}
+	return snapshots, rows.Err()
+}
+
+// AddMasterySnapshots records champion masteries, skipping any whose points are the
+// same as the player's latest one for the champion. It returns how many were recorded.
+func (s *SQLiteStore) AddMasterySnapshots(snapshots []MasterySnapshot) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	added := 0
+	for _, ms := range snapshots {
+		var points int64
+		err = tx.QueryRow(`SELECT champion_points FROM mastery_snapshots
+			WHERE player_id = ? AND champion_id = ? ORDER BY taken_at DESC LIMIT 1`,
+			ms.PlayerID, ms.ChampionID).Scan(&points)
+		if err == nil && points == ms.ChampionPoints {
+			continue
+		} else if err != nil && err != sql.ErrNoRows {
+			tx.Rollback()
+			return 0, err
+		}
+		_, err = tx.Exec(`INSERT OR REPLACE INTO mastery_snapshots (player_id, champion_id, taken_at,
+			champion_level, champion_points, last_play_time) VALUES (?, ?, ?, ?, ?, ?)`,
+			ms.PlayerID, ms.ChampionID, ms.TakenAt.UnixNano()/int64(time.Millisecond),
+			ms.ChampionLevel, ms.ChampionPoints, ms.LastPlayed.UnixNano()/int64(time.Millisecond))
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		added++
+	}
+	return added, tx.Commit()
+}
+
+// Masteries returns the player's latest recorded mastery of each champion, most points first
+func (s *SQLiteStore) Masteries(playerID string) ([]MasterySnapshot, error) {
+	rows, err := s.db.Query(`SELECT champion_id, MAX(taken_at), champion_level, champion_points, last_play_time
+		FROM mastery_snapshots WHERE player_id = ? GROUP BY champion_id ORDER BY champion_points DESC`,
+		playerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snapshots []MasterySnapshot
+	for rows.Next() {
+		ms := MasterySnapshot{PlayerID: playerID}
+		var takenAt, lastPlayed int64
+		if err = rows.Scan(&ms.ChampionID, &takenAt, &ms.ChampionLevel, &ms.ChampionPoints, &lastPlayed); err != nil {
+			return nil, err
+		}
+		ms.TakenAt = time.Unix(0, takenAt*int64(time.Millisecond))
+		ms.LastPlayed = time.Unix(0, lastPlayed*int64(time.Millisecond))
+		snapshots = append(snapshots, ms)
+	}
+	return snapshots, rows.Err()
+}
+
+// Matches returns the matches satisfying the query, most recent first
+func (s *SQLiteStore) Matches(q MatchQuery) ([]client.Match, error) {
+	var where []string
+	var args []interface{}
+	if len(q.PlayerID) > 0 {
+		cond := "p.player_id = ?"
+		args = append(args, q.PlayerID)
+		if q.ChampionID != 0 {
+			cond += " AND p.champion_id = ?"
+			args = append(args, q.ChampionID)
+		}
+		where = append(where, "EXISTS (SELECT 1 FROM participants p WHERE p.match_id = m.match_id AND "+cond+")")
+	} else if q.ChampionID != 0 {
+		where = append(where, "EXISTS (SELECT 1 FROM participants p WHERE p.match_id = m.match_id AND p.champion_id = ?)")
+		args = append(args, q.ChampionID)
+	}
+	if len(q.QueueIDs) > 0 {
+		where = append(where, "m.queue_id IN (?"+strings.Repeat(", ?", len(q.QueueIDs)-1)+")")
+		for _, queueID := range q.QueueIDs {
+			args = append(args, queueID)
+		}
+	}
+	if len(q.Patch) > 0 {
+		where = append(where, "m.patch = ?")
+		args = append(args, gamePatch(q.Patch))
+	}
+
+	query := "SELECT m.data FROM matches m"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY m.game_creation DESC"
+	return s.queryMatches(query, args...)
+}
+
+func (s *SQLiteStore) queryMatches(query string, args ...interface{}) ([]client.Match, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var matches []client.Match
+	for rows.Next() {
+		var data string
+		if err = rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var match client.Match
+		if err = json.Unmarshal([]byte(data), &match); err != nil {
+			return nil, err
+		}
+		matches = append(matches, match)
+	}
+	return matches, rows.Err()
+}
+
+// ResolveName returns the players who have played under name, a Riot ID (Name#TAG)
+// or a bare game name or summoner name, ignoring case: one entry per player, with the
+// last time they used the name, most recently seen first.
+func (s *SQLiteStore) ResolveName(name string) ([]PlayerName, error) {
+	query := `SELECT player_id, game_name, tag_line, summoner_name, first_seen, MAX(last_seen)
+		FROM summoner_names WHERE `
+	var args []interface{}
+	if gameName, tagLine, ok := strings.Cut(name, "#"); ok {
+		query += "game_name = ? COLLATE NOCASE AND tag_line = ? COLLATE NOCASE"
+		args = append(args, gameName, tagLine)
+	} else {
+		query += "(game_name = ? COLLATE NOCASE OR summoner_name = ? COLLATE NOCASE)"
+		args = append(args, name, name)
+	}
+	query += " GROUP BY player_id ORDER BY MAX(last_seen) DESC"
+	return s.queryNames(query, args...)
+}
+
+// NameHistory returns the names the player has been seen under, most recent first
+func (s *SQLiteStore) NameHistory(playerID string) ([]PlayerName, error) {
+	return s.queryNames(`SELECT player_id, game_name, tag_line, summoner_name, first_seen, last_seen
+		FROM summoner_names WHERE player_id = ? ORDER BY last_seen DESC`, playerID)
+}
+
+// AddPlayerAlias records that alias, such as the account ID of Match-V4 games, is
+// another ID of the player. The games stored under the alias are stored again under
+// the player's ID and its names moved to them, so reports on the player include them.
+func (s *SQLiteStore) AddPlayerAlias(alias, playerID string) error {
+	if alias == playerID {
+		return nil
+	}
+	matches, err := s.queryMatches(`SELECT m.data FROM matches m WHERE EXISTS
+		(SELECT 1 FROM participants p WHERE p.match_id = m.match_id AND p.player_id = ?)`, alias)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err = tx.Exec("INSERT OR REPLACE INTO player_aliases (alias, player_id) VALUES (?, ?)", alias, playerID); err != nil {
+		tx.Rollback()
+		return err
+	}
+	for i := range matches {
+		if err = deleteMatch(tx, matches[i].Metadata.MatchID); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err = insertMatch(tx, &matches[i]); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	// Names of pruned games are only in the name history, so they are moved rather than rebuilt
+	_, err = tx.Exec(`INSERT INTO summoner_names (player_id, game_name, tag_line, summoner_name,
+		first_seen, last_seen) SELECT ?, game_name, tag_line, summoner_name, first_seen, last_seen
+		FROM summoner_names WHERE player_id = ?
+		ON CONFLICT (player_id, game_name, tag_line, summoner_name) DO UPDATE SET
+		first_seen = MIN(first_seen, excluded.first_seen), last_seen = MAX(last_seen, excluded.last_seen)`,
+		playerID, alias)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err = tx.Exec("DELETE FROM summoner_names WHERE player_id = ?", alias); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// SetPlayerPlatform records the platform the player was looked up on
+func (s *SQLiteStore) SetPlayerPlatform(playerID, platform string) error {
+	_, err := s.db.Exec(`INSERT OR REPLACE INTO player_platforms (player_id, platform, looked_up_at)
+		VALUES (?, ?, ?)`, playerID, platform, time.Now().UnixNano()/int64(time.Millisecond))
+	return err
+}
+
+// PlayerPlatform returns the platform the player was last looked up on, or "" if they never were
+func (s *SQLiteStore) PlayerPlatform(playerID string) (string, error) {
+	var platform string
+	err := s.db.QueryRow("SELECT platform FROM player_platforms WHERE player_id = ?", playerID).Scan(&platform)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return platform, err
+}
+
+func (s *SQLiteStore) queryNames(query string, args ...interface{}) ([]PlayerName, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var names []PlayerName
+	for rows.Next() {
+		var pn PlayerName
+		var firstSeen, lastSeen int64
+		if err = rows.Scan(&pn.PlayerID, &pn.GameName, &pn.TagLine, &pn.SummonerName, &firstSeen, &lastSeen); err != nil {
+			return nil, err
+		}
+		pn.FirstSeen = time.Unix(0, firstSeen*int64(time.Millisecond))
+		pn.LastSeen = time.Unix(0, lastSeen*int64(time.Millisecond))
+		names = append(names, pn)
+	}
+	return names, rows.Err()
+}
+
+// CountMatches returns the number of matches in the store
+func (s *SQLiteStore) CountMatches() (int, error) {
+	var count int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM matches").Scan(&count)
+	return count, err
+}
+
+// GameVersions returns the distinct game versions of the stored matches
+func (s *SQLiteStore) GameVersions() ([]string, error) {
+	rows, err := s.db.Query("SELECT DISTINCT game_version FROM matches")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []string
+	for rows.Next() {
+		var version string
+		if err = rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+	return versions, rows.Err()
+}
+
+// FilterMatchIDs returns the matchIDs not already found in the store
+func (s *SQLiteStore) FilterMatchIDs(matchIDs []string) ([]string, error) {
+	var filteredMatchIDs []string
+	for _, matchID := range matchIDs {
+		var found int
+		err := s.db.QueryRow("SELECT COUNT(*) FROM matches WHERE match_id = ?", matchID).Scan(&found)
+		if err != nil {
+			return nil, err
+		}
+		if found == 0 {
+			filteredMatchIDs = append(filteredMatchIDs, matchID)
+		}
+	}
+	return filteredMatchIDs, nil
+}
+
+// Prune deletes all but the most recent keep matches, returning how many were deleted
+func (s *SQLiteStore) Prune(keep int) (int, error) {
+	rows, err := s.db.Query("SELECT match_id FROM matches ORDER BY game_creation DESC LIMIT -1 OFFSET ?", keep)
+	if err != nil {
+		return 0, err
+	}
+	var matchIDs []string
+	for rows.Next() {
+		var matchID string
+		if err = rows.Scan(&matchID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		matchIDs = append(matchIDs, matchID)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+	return len(matchIDs), s.DeleteMatches(matchIDs)
+}
@@ -0,0 +1,195 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/WhiteAcres/leaguestats/client"
+)
+
+func openTestStore(t *testing.T) *SQLiteStore {
+	store, err := OpenSQLite(filepath.Join(t.TempDir(), "matches.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestPlayerPlatform(t *testing.T) {
+	store := openTestStore(t)
+	if platform, err := store.PlayerPlatform("puuid-a"); err != nil || platform != "" {
+		t.Errorf("PlayerPlatform() before a lookup = %q, %v, want \"\"", platform, err)
+	}
+	for _, platform := range []string{"NA1", "EUW1"} {
+		if err := store.SetPlayerPlatform("puuid-a", platform); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if platform, err := store.PlayerPlatform("puuid-a"); err != nil || platform != "EUW1" {
+		t.Errorf("PlayerPlatform() = %q, %v, want the latest lookup's EUW1", platform, err)
+	}
+}
+
+// renamedMatches are a player's games under an old Riot ID and, later, a new one,
+// and a Match-V4 game from before Riot IDs under their summoner name
+func renamedMatches() []*client.Match {
+	match := func(matchID string, gameCreation int64, p client.Participant) *client.Match {
+		p.ParticipantID = 1
+		return &client.Match{
+			Metadata: client.MatchMetadata{MatchID: matchID},
+			Info:     client.MatchInfo{GameCreation: gameCreation, GameVersion: "14.3.1", Participants: []client.Participant{p}},
+		}
+	}
+	return []*client.Match{
+		match("NA1_1", 1000, client.Participant{PuuID: "puuid-a", RiotIDGameName: "Old Name", RiotIDTagline: "NA1"}),
+		match("NA1_2", 2000, client.Participant{PuuID: "puuid-a", RiotIDGameName: "New Name", RiotIDTagline: "NA1"}),
+		match("NA1_3", 3000, client.Participant{PuuID: "puuid-a", RiotIDGameName: "New Name", RiotIDTagline: "NA1"}),
+		match("NA1_0", 500, client.Participant{AccountID: "acc-b", SummonerName: "Old Name"}),
+	}
+}
+
+func TestResolveName(t *testing.T) {
+	store := openTestStore(t)
+	if err := store.UpsertMatches(renamedMatches()); err != nil {
+		t.Fatal(err)
+	}
+	assertResolvesNames(t, store)
+}
+
+// assertResolvesNames checks that both of puuid-a's names lead back to them
+func assertResolvesNames(t *testing.T, store *SQLiteStore) {
+	t.Helper()
+	for _, name := range []string{"New Name#NA1", "old name#na1", "New Name"} {
+		players, err := store.ResolveName(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(players) != 1 || players[0].PlayerID != "puuid-a" {
+			t.Errorf("ResolveName(%q) = %+v, want puuid-a", name, players)
+		}
+	}
+
+	// The bare name is also the summoner name of another, earlier player
+	players, err := store.ResolveName("Old Name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(players) != 2 || players[0].PlayerID != "puuid-a" || players[1].PlayerID != "account:acc-b" {
+		t.Errorf("ResolveName(\"Old Name\") = %+v, want puuid-a then account:acc-b", players)
+	}
+
+	history, err := store.NameHistory("puuid-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[0].RiotID() != "New Name#NA1" || history[1].RiotID() != "Old Name#NA1" {
+		t.Fatalf("NameHistory() = %+v, want New Name#NA1 then Old Name#NA1", history)
+	}
+	if first, last := history[0].FirstSeen.UnixNano()/1e6, history[0].LastSeen.UnixNano()/1e6; first != 2000 || last != 3000 {
+		t.Errorf("New Name seen %d to %d, want 2000 to 3000", first, last)
+	}
+
+	matches, err := store.Matches(MatchQuery{PlayerID: "puuid-a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 3 {
+		t.Errorf("Matches() of puuid-a = %d matches, want 3 across both names", len(matches))
+	}
+}
+
+func TestBackfillPlayerIDs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "matches.db")
+	store, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = store.UpsertMatches(renamedMatches()); err != nil {
+		t.Fatal(err)
+	}
+	// Put the rows back as they were stored before players were identified by ID
+	if _, err = store.db.Exec("UPDATE participants SET player_id = NULL; DELETE FROM summoner_names"); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	store, err = OpenSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	var missing int
+	if err = store.db.QueryRow("SELECT COUNT(*) FROM participants WHERE player_id IS NULL").Scan(&missing); err != nil {
+		t.Fatal(err)
+	}
+	if missing != 0 {
+		t.Errorf("%d participants left without a player ID", missing)
+	}
+	assertResolvesNames(t, store)
+}
+
+// bothAPIsMatches are one Match-V4 game of a player, known there by account ID, and one
+// Match-V5 game, where they are known by PUUID
+func bothAPIsMatches() []*client.Match {
+	legacy := &client.MatchV4{GameID: 100, PlatformID: "NA1", GameCreation: 1000, GameVersion: "10.25.1",
+		ParticipantIdentities: []client.ParticipantIdentity{{ParticipantID: 1,
+			Player: client.Player{AccountID: "acc-b", SummonerName: "Bravo"}}},
+		Participants: []client.ParticipantV4{{ParticipantID: 1, TeamID: 100, ChampionID: 64}},
+	}
+	v4 := legacy.ToV5()
+	v5 := &client.Match{
+		Metadata: client.MatchMetadata{MatchID: "NA1_200"},
+		Info: client.MatchInfo{GameCreation: 2000, GameVersion: "14.3.1", Participants: []client.Participant{
+			{ParticipantID: 1, PuuID: "puuid-b", RiotIDGameName: "Bravo", RiotIDTagline: "NA1", ChampionID: 64},
+		}},
+	}
+	return []*client.Match{&v4, v5}
+}
+
+func TestAddPlayerAlias(t *testing.T) {
+	store := openTestStore(t)
+	if err := store.UpsertMatches(bothAPIsMatches()); err != nil {
+		t.Fatal(err)
+	}
+	matches, err := store.Matches(MatchQuery{PlayerID: "puuid-b"})
+	if err != nil || len(matches) != 1 {
+		t.Fatalf("matches of puuid-b before the alias = %d, %v, want the Match-V5 game only", len(matches), err)
+	}
+
+	if err = store.AddPlayerAlias("account:acc-b", "puuid-b"); err != nil {
+		t.Fatal(err)
+	}
+	matches, err = store.Matches(MatchQuery{PlayerID: "puuid-b", ChampionID: 64})
+	if err != nil || len(matches) != 2 {
+		t.Fatalf("matches of puuid-b after the alias = %d, %v, want both games", len(matches), err)
+	}
+	// The Match-V4 game's participant is now the player too
+	if p := matches[1].Info.Participants[0]; p.PlayerID() != "puuid-b" || p.AccountID != "acc-b" {
+		t.Errorf("Match-V4 participant = %q (account %q), want puuid-b", p.PlayerID(), p.AccountID)
+	}
+	if matches, _ = store.Matches(MatchQuery{PlayerID: "account:acc-b"}); len(matches) != 0 {
+		t.Errorf("%d matches left under the alias", len(matches))
+	}
+
+	// The summoner name of the Match-V4 game leads to the player
+	names, err := store.ResolveName("bravo")
+	if err != nil || len(names) != 1 || names[0].PlayerID != "puuid-b" {
+		t.Errorf("ResolveName(\"bravo\") = %+v, %v, want puuid-b only", names, err)
+	}
+	history, err := store.NameHistory("puuid-b")
+	if err != nil || len(history) != 2 || history[1].SummonerName != "Bravo" {
+		t.Errorf("NameHistory(puuid-b) = %+v, %v, want the Riot ID and the summoner name", history, err)
+	}
+
+	// Match-V4 games stored after the alias go straight to the player
+	if err = store.DeleteMatches([]string{"NA1_100"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = store.UpsertMatches(bothAPIsMatches()[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if matches, _ = store.Matches(MatchQuery{PlayerID: "puuid-b"}); len(matches) != 2 {
+		t.Errorf("matches of puuid-b after storing the Match-V4 game again = %d, want 2", len(matches))
+	}
+}
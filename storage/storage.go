@@ -5,138 +5,89 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
-	"os/user"
 
 	"github.com/WhiteAcres/leaguestats/client"
+	"github.com/WhiteAcres/leaguestats/paths"
 )
 
-// Storage - json representation of all the Match objects
+// Storage - legacy json representation of all the Match objects, keyed by match ID
 type Storage struct {
-	Data map[int64]client.Match
+	Data map[string]client.Match
 }
 
-// Need a load func (We may not actually need an InitilizeStorage func - we could just include empty init logic here)
-func createDirIfNotExist(dir string) {
-	if _, err := os.Stat(dir); os.IsNotExist(err) {
-		err = os.MkdirAll(dir, 0755)
-		if err != nil {
-			log.Fatal(err)
-		}
-	}
+// rawStorage - storage file as read from disk, before Match-V4 records are converted
+type rawStorage struct {
+	Data map[string]json.RawMessage
 }
 
-func getStorageFile() *os.File {
-	// Create the conf directory if not exists
-	user, err := user.Current()
-	if err != nil {
-		log.Fatal(err)
+// decodeMatch decodes a stored match, converting it if it was saved from Match-V4
+func decodeMatch(raw json.RawMessage) (client.Match, error) {
+	var probe struct {
+		Metadata *client.MatchMetadata
 	}
-	confDirPath := user.HomeDir + "\\AppData\\Local\\leaguestats"
-	createDirIfNotExist(confDirPath)
-
-	// Read the conf file and create if it doesn't exist
-	confFilePath := confDirPath + "\\storage.json"
-	f, err := os.OpenFile(confFilePath, os.O_RDONLY|os.O_CREATE, 0666)
-	if err != nil {
-		log.Fatal(err)
+	if err := json.Unmarshal(raw, &probe); err != nil {
+		return client.Match{}, err
+	}
+	if probe.Metadata == nil {
+		var m client.MatchV4
+		if err := json.Unmarshal(raw, &m); err != nil {
+			return client.Match{}, err
+		}
+		return m.ToV5(), nil
 	}
-	return f
+	var m client.Match
+	err := json.Unmarshal(raw, &m)
+	return m, err
 }
 
-// LoadStorage loads the storage file
-func LoadStorage() *Storage {
-	f := getStorageFile()
-	defer f.Close()
-	b, err := ioutil.ReadAll(f)
-	if err != nil {
-		log.Fatal(err)
-	}
-	var storage Storage
-	err = json.Unmarshal(b, &storage)
-	if err != nil || len(b) == 0 {
-		return &Storage{make(map[int64]client.Match)}
-	}
-	return &storage
+func getStorageFile() string {
+	return paths.DataFile("storage.json")
 }
 
-// SaveStorage saves the config file
-func (s *Storage) SaveStorage() {
-	fileData, err := json.MarshalIndent(s, "", "    ")
+// LoadJSON loads a legacy storage.json file, converting any Match-V4 records
+func LoadJSON(path string) (*Storage, error) {
+	b, err := ioutil.ReadFile(path)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
-	f := getStorageFile()
-	defer f.Close()
-	_, err = f.Write(fileData)
+	storage := &Storage{make(map[string]client.Match)}
+	if len(b) == 0 {
+		return storage, nil
+	}
+	var raw rawStorage
+	err = json.Unmarshal(b, &raw)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
-}
-
-// // Prune modifies storage to only keep the most recent total number of records
-// func (s *Storage) Prune(total int) {
-
-// 	// Dump all stored matches into a slice
-// 	var matches []*client.Match
-// 	for _, match := range s.Data {
-// 		matches = append(matches, &match)
-// 	}
-
-// 	// Sort all the matches (with the most recent being at the lowest slice index)
-// 	sort.Slice(matches, func(i, j int) bool {
-// 		return matches[i].GameCreation > matches[j].GameCreation
-// 	})
-
-// 	//Only keep the latest total number of games
-// 	fmt.Println(len(matches))
-// 	if len(matches) > total {
-// 		matches = matches[0:total]
-// 		newS := Storage{make(map[int64]client.Match)}
-// 		for _, match := range matches {
-// 			newS.Data[match.GameID] = *match
-// 		}
-
-// 		// Overwrite the old storage with the new one
-// 		newS.SaveStorage()
-// 	}
-// }
-
-// UpsertRecords inserts matches into the storage if they don't exist or updates them
-func (s *Storage) UpsertRecords(matches []*client.Match) {
-	for _, match := range matches {
-		s.Data[match.GameID] = *match
+	for _, r := range raw.Data {
+		match, err := decodeMatch(r)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		storage.Data[match.Metadata.MatchID] = match
 	}
-	s.SaveStorage()
+	return storage, nil
 }
 
-// DeleteRecords deletes matches from the storage
-func (s *Storage) DeleteRecords(matches []*client.Match) {
-	for _, match := range matches {
-		delete(s.Data, match.GameID)
+// ImportJSON copies the matches of a legacy storage.json file into the store,
+// then renames the file so it is only imported once
+func ImportJSON(store Store, path string) (int, error) {
+	s, err := LoadJSON(path)
+	if err != nil {
+		return 0, err
 	}
-	s.SaveStorage()
-}
-
-func contains(s []int64, e int64) bool {
-	for _, a := range s {
-		if a == e {
-			return true
-		}
+	matches := make([]*client.Match, 0, len(s.Data))
+	for matchID := range s.Data {
+		match := s.Data[matchID]
+		matches = append(matches, &match)
 	}
-	return false
-}
-
-// FilterGameIDs returns a slice of gameIDs not already found in storage
-func (s *Storage) FilterGameIDs(gameIDs []int64) []int64 {
-	var filteredGameIDs []int64
-	sGameIDs := make([]int64, 0, len(s.Data))
-	for sGameID := range s.Data {
-		sGameIDs = append(sGameIDs, sGameID)
+	if err = store.UpsertMatches(matches); err != nil {
+		return 0, err
 	}
-	for _, gameID := range gameIDs {
-		if contains(sGameIDs, gameID) == false {
-			filteredGameIDs = append(filteredGameIDs, gameID)
-		}
+	if err = os.Rename(path, path+".imported"); err != nil {
+		return 0, err
 	}
-	return filteredGameIDs
+	log.Printf("Imported %d matches from %s\n", len(matches), path)
+	return len(matches), nil
 }
@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// legacyStorage is a storage.json holding one Match-V5 match and one Match-V4 match
+const legacyStorage = `{"Data": {
+	"NA1_200": {
+		"Metadata": {"DataVersion": "2", "MatchID": "NA1_200", "Participants": ["puuid-a"]},
+		"Info": {"GameCreation": 1700000000000, "GameDuration": 1800, "GameID": 200,
+			"GameVersion": "14.3.556.1234", "PlatformID": "NA1", "QueueID": 420,
+			"Participants": [{"ParticipantID": 1, "PuuID": "puuid-a", "RiotIDGameName": "Alpha",
+				"RiotIDTagline": "NA1", "TeamID": 100, "ChampionID": 103, "Win": true}]}
+	},
+	"100": {
+		"GameID": 100, "PlatformID": "na1", "QueueID": 420, "GameVersion": "10.25.348.1797",
+		"GameCreation": 1600000000000, "GameDuration": 1500,
+		"ParticipantIdentities": [{"ParticipantID": 1, "Player": {"SummonerName": "Bravo", "AccountID": "acc-b"}}],
+		"Participants": [{"ParticipantID": 1, "TeamID": 200, "ChampionID": 64,
+			"Stats": {"Win": false, "Kills": 4, "Deaths": 2, "Assists": 7},
+			"Timeline": {"Lane": "JUNGLE", "Role": "NONE"}}]
+	}
+}}`
+
+func writeLegacyStorage(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "storage.json")
+	if err := ioutil.WriteFile(path, []byte(legacyStorage), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadJSON(t *testing.T) {
+	s, err := LoadJSON(writeLegacyStorage(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Data) != 2 {
+		t.Fatalf("loaded %d matches, want 2", len(s.Data))
+	}
+
+	v5, ok := s.Data["NA1_200"]
+	if !ok {
+		t.Fatal("Match-V5 match NA1_200 not loaded")
+	}
+	if p := v5.Info.Participants[0]; p.PlayerID() != "puuid-a" || p.ChampionID != 103 {
+		t.Errorf("Match-V5 participant = %+v", p)
+	}
+
+	// Match-V4 records are keyed by their converted match ID
+	v4, ok := s.Data["NA1_100"]
+	if !ok {
+		t.Fatal("Match-V4 match not converted to NA1_100")
+	}
+	if v4.Metadata.DataVersion != "v4" {
+		t.Errorf("DataVersion = %q, want v4", v4.Metadata.DataVersion)
+	}
+	if got := v4.GameDurationSeconds(); got != 1500 {
+		t.Errorf("GameDurationSeconds() = %d, want 1500", got)
+	}
+	p := v4.Info.Participants[0]
+	if p.PlayerID() != "account:acc-b" || p.SummonerName != "Bravo" {
+		t.Errorf("Match-V4 participant identity = %q %q", p.PlayerID(), p.SummonerName)
+	}
+	if p.Kills != 4 || p.Deaths != 2 || p.Assists != 7 || p.Win {
+		t.Errorf("Match-V4 participant stats = %+v", p)
+	}
+	if p.Position() != "JUNGLE" {
+		t.Errorf("Position() = %q, want JUNGLE", p.Position())
+	}
+}
+
+func TestLoadJSONEmptyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "storage.json")
+	if err := ioutil.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := LoadJSON(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Data) != 0 {
+		t.Errorf("loaded %d matches from an empty file", len(s.Data))
+	}
+}
+
+func TestImportJSON(t *testing.T) {
+	path := writeLegacyStorage(t)
+	store, err := OpenSQLite(filepath.Join(t.TempDir(), "matches.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	imported, err := ImportJSON(store, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported != 2 {
+		t.Errorf("imported %d matches, want 2", imported)
+	}
+	if count, err := store.CountMatches(); err != nil || count != 2 {
+		t.Errorf("CountMatches() = %d, %v, want 2", count, err)
+	}
+
+	matches, err := store.Matches(MatchQuery{PlayerID: "account:acc-b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || matches[0].Metadata.MatchID != "NA1_100" {
+		t.Errorf("matches of the Match-V4 player = %v", matches)
+	}
+
+	// The file is renamed so the next Open doesn't import it again
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("storage.json still present after import: %v", err)
+	}
+	if _, err := os.Stat(path + ".imported"); err != nil {
+		t.Errorf("storage.json.imported missing: %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"os"
+	"time"
+
+	"github.com/WhiteAcres/leaguestats/client"
+	"github.com/WhiteAcres/leaguestats/patch"
+	"github.com/WhiteAcres/leaguestats/paths"
+)
+
+// Store - persistent storage for matches, their timelines, ranked standings and champion mastery
+type Store interface {
+	// UpsertMatches inserts matches into the store or replaces them if they already exist
+	UpsertMatches(matches []*client.Match) error
+	// DeleteMatches deletes matches and their timelines from the store
+	DeleteMatches(matchIDs []string) error
+	// Matches returns the matches satisfying the query, most recent first
+	Matches(q MatchQuery) ([]client.Match, error)
+	// CountMatches returns the number of matches in the store
+	CountMatches() (int, error)
+	// GameVersions returns the distinct game versions of the stored matches
+	GameVersions() ([]string, error)
+	// FilterMatchIDs returns the matchIDs not already found in the store
+	FilterMatchIDs(matchIDs []string) ([]string, error)
+	// Prune deletes all but the most recent keep matches, returning how many were deleted
+	Prune(keep int) (int, error)
+	// UpsertTimelines inserts match timelines into the store or replaces them if they already exist
+	UpsertTimelines(timelines []*client.Timeline) error
+	// Timelines returns the stored timelines of the matches, keyed by match ID
+	Timelines(matchIDs []string) (map[string]*client.Timeline, error)
+	// FilterTimelineIDs returns the matchIDs whose timeline is not already found in the store
+	FilterTimelineIDs(matchIDs []string) ([]string, error)
+	// AddLeagueSnapshots records ranked standings, skipping any that are the same as the
+	// player's latest one in the queue, and returns how many were recorded
+	AddLeagueSnapshots(snapshots []LeagueSnapshot) (int, error)
+	// LeagueSnapshots returns a player's recorded standings in a queue, oldest first
+	LeagueSnapshots(playerID, queueType string) ([]LeagueSnapshot, error)
+	// AddMasterySnapshots records champion masteries, skipping any whose points are the
+	// same as the player's latest one for the champion, and returns how many were recorded
+	AddMasterySnapshots(snapshots []MasterySnapshot) (int, error)
+	// Masteries returns a player's latest recorded mastery of each champion, most points first
+	Masteries(playerID string) ([]MasterySnapshot, error)
+	// ResolveName returns the players who have played under a Riot ID (Name#TAG),
+	// game name or summoner name, ignoring case, most recently seen first
+	ResolveName(name string) ([]PlayerName, error)
+	// NameHistory returns the names a player has been seen under, most recent first
+	NameHistory(playerID string) ([]PlayerName, error)
+	// AddPlayerAlias records that alias, such as the account ID of Match-V4 games, is
+	// another ID of a player, storing the games under the alias again under the player
+	AddPlayerAlias(alias, playerID string) error
+	// SetPlayerPlatform records the platform a player was looked up on
+	SetPlayerPlatform(playerID, platform string) error
+	// PlayerPlatform returns the platform a player was last looked up on, or "" if
+	// they never were
+	PlayerPlatform(playerID string) (string, error)
+	// Close releases the store
+	Close() error
+}
+
+// PlayerName - a name a player was seen under in stored matches
+type PlayerName struct {
+	// PlayerID is the player's client.Participant.PlayerID
+	PlayerID     string
+	GameName     string
+	TagLine      string
+	SummonerName string
+	FirstSeen    time.Time
+	LastSeen     time.Time
+}
+
+// RiotID returns the name as Name#TAG, or the summoner name if it predates Riot IDs
+func (pn PlayerName) RiotID() string {
+	if len(pn.GameName) == 0 {
+		return pn.SummonerName
+	}
+	return pn.GameName + "#" + pn.TagLine
+}
+
+// LeagueSnapshot - a player's ranked standing in a queue at one point in time
+type LeagueSnapshot struct {
+	PlayerID string
+	// QueueType is client.QueueTypeSolo or client.QueueTypeFlex
+	QueueType    string
+	TakenAt      time.Time
+	Tier         string
+	Rank         string
+	LeaguePoints int64
+	Wins         int64
+	Losses       int64
+}
+
+// MasterySnapshot - a player's mastery of a champion at one point in time
+type MasterySnapshot struct {
+	PlayerID       string
+	ChampionID     int64
+	TakenAt        time.Time
+	ChampionLevel  int64
+	ChampionPoints int64
+	LastPlayed     time.Time
+}
+
+// MatchQuery - narrows down the matches returned by a Store. Zero fields match everything.
+type MatchQuery struct {
+	// PlayerID matches a participant's client.Participant.PlayerID
+	PlayerID string
+	// ChampionID matches the champion played by PlayerID, or by anyone if it isn't set
+	ChampionID int64
+	QueueIDs   []int64
+	// Patch matches the major.minor patch of the game version, e.g. "14.3";
+	// a longer version such as "14.3.1" matches its patch
+	Patch string
+}
+
+// gamePatch returns the major.minor patch of a game version such as 14.3.556.1234
+func gamePatch(gameVersion string) string {
+	return patch.Bucket(gameVersion)
+}
+
+// Open opens the default match store, importing the legacy storage.json into it if present
+func Open() (Store, error) {
+	store, err := OpenSQLite(paths.DataFile("matches.db"))
+	if err != nil {
+		return nil, err
+	}
+	jsonPath := getStorageFile()
+	if _, err := os.Stat(jsonPath); err == nil {
+		if _, err := ImportJSON(store, jsonPath); err != nil {
+			store.Close()
+			return nil, err
+		}
+	}
+	return store, nil
+}